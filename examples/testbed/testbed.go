@@ -2,14 +2,22 @@ package main
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"log"
+	"math"
 	"os"
+	"time"
 
 	"gioui.org/app"
+	"gioui.org/f32"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"github.com/bradbev/imgio/src/imgio"
+	"github.com/bradbev/imgio/src/imgio/nodes"
+	"github.com/bradbev/imgio/src/imgio/plot"
 )
 
 func main() {
@@ -27,6 +35,22 @@ func main() {
 	app.Main()
 }
 
+// settings shows off Inspect
+type settings struct {
+	Title    string
+	Volume   float64 `imgio:"min=0,max=1,step=0.005"`
+	Lives    int     `imgio:"min=0,max=9"`
+	Tint     color.NRGBA
+	Debug    bool
+	Seed     int64  `imgio:"readonly"`
+	Password string `imgio:"hidden"`
+	Spawn    struct {
+		X, Y float32
+	}
+	Levels []string
+	Scores map[string]int
+}
+
 type C = layout.Context
 type D = layout.Dimensions
 
@@ -35,7 +59,23 @@ func draw(w *app.Window) error {
 
 	var (
 		inputText string
+		position  [3]float64
+		tint      = [4]float64{1, 0.5, 0.25, 1}
+		sine      = make([]float32, 64)
+		gain      = 0.5
 	)
+	xs, ys := make([]float64, len(sine)), make([]float64, len(sine))
+	for k := range sine {
+		sine[k] = float32(math.Sin(float64(k) / 8))
+		xs[k], ys[k] = float64(k)/8, math.Sin(float64(k)/8)
+	}
+	nan := float32(math.NaN())
+	sprite := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			sprite.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 32), G: uint8(y * 32), B: 0x80, A: 0xff})
+		}
+	}
 
 	/*
 		var inputFloat2 float64
@@ -45,10 +85,32 @@ func draw(w *app.Window) error {
 	*/
 
 	imgio.Init(w)
+	gravity, damping, iterations := 9.8, float32(0.1), 4
+	playerName, tracerColor := "player", color.NRGBA{R: 0xff, A: 0xff}
+	imgio.Tweak("physics/gravity", &gravity, imgio.Range(0, 20))
+	imgio.Tweak("physics/damping", &damping, imgio.Range(0, 1))
+	imgio.Tweak("physics/solver/iterations", &iterations, imgio.Range(1, 32))
+	imgio.Tweak("player/name", &playerName)
+	imgio.Tweak("player/tracer", &tracerColor)
+	imgio.Default().SetAutosave(5*time.Second, func(err error) {
+		log.Println(err)
+	})
+
+	samples := imgio.NewScrollingBuffer[float64](2000)
+	go func() {
+		start := time.Now()
+		for range time.Tick(20 * time.Millisecond) {
+			t := time.Since(start).Seconds()
+			samples.Push(math.Sin(t) + 0.2*math.Sin(7*t))
+		}
+	}()
 
-	wm := &imgio.WindowManager{}
-	imgio.TempSetWm(wm)
 	win_open := true
+	layouts_open := false
+	tweaks_open := false
+	showPlot := true
+	cfg := settings{Title: "Testbed", Volume: 0.8, Lives: 3, Seed: 42,
+		Levels: []string{"intro", "caves"}, Scores: map[string]int{"bev": 100}}
 	for {
 		// listen for events in the window.
 		switch e := w.Event().(type) {
@@ -56,8 +118,7 @@ func draw(w *app.Window) error {
 		// this is sent when the application should re-render.
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, e)
-			imgio.SetContext(gtx)
-			wm.Layout(gtx)
+			imgio.NewFrame(gtx)
 
 			imgio.Begin("debug", &win_open, func(im *imgio.Im) {
 				im.Text("Hello world %v", 123)
@@ -99,15 +160,96 @@ func draw(w *app.Window) error {
 				im.ColorEdit("ContrastBg", &imgio.GetTheme().ContrastBg)
 				im.ColorEdit("Fg", &imgio.GetTheme().Fg)
 				im.ColorEdit("ContrastFg", &imgio.GetTheme().ContrastFg)
+				im.WithPersist(func(im *imgio.Im) {
+					im.DragFloat3("Position", &position, 0.1, -100, 100, "%.2f")
+					im.ColorPicker4("Tint", &tint)
+					im.Checkbox("Show plot", &showPlot)
+				})
+				im.ProgressBar(float32(tint[0]), f32.Point{}, "")
+				im.Spinner("Working")
+				im.PlotLines("Sine", sine, 0, "", nan, nan, f32.Point{})
+				im.PlotHistogram("Sine bars", sine, 0, "", nan, nan, f32.Point{})
+				imgio.PlotScrolling(im, "Samples", samples, f32.Point{})
 				im.Text("Test text")
+				dl := im.ItemDrawList(f32.Pt(0, 60))
+				dl.AddRectFilled(f32.Pt(4, 4), f32.Pt(56, 56), imgio.GetTheme().ContrastBg, 6)
+				dl.AddCircle(f32.Pt(90, 30), 24, imgio.GetTheme().Fg, 2)
+				dl.AddBezierCubic(f32.Pt(130, 50), f32.Pt(160, -20), f32.Pt(200, 80), f32.Pt(240, 10), imgio.GetTheme().Fg, 2)
+				dl.AddText(f32.Pt(260, 20), imgio.GetTheme().Fg, "DrawList")
+				im.WithSameLine(func(im *imgio.Im) {
+					im.WithImageFilter(paint.FilterNearest, func(im *imgio.Im) {
+						im.Image(sprite, f32.Pt(64, 64), f32.Point{}, f32.Pt(1, 1), color.NRGBA{})
+						im.Image(sprite, f32.Pt(64, 64), f32.Point{}, f32.Pt(0.5, 0.5), color.NRGBA{R: 0xff, G: 0x80, B: 0x80, A: 0xff})
+					})
+					if im.ImageButton("sprite", sprite, f32.Pt(32, 32)) {
+						fmt.Println("sprite clicked")
+					}
+				})
+				im.ImageInspector("Sprite", sprite)
+				im.TreeNode("Settings", func(im *imgio.Im) {
+					im.Inspect("Settings", &cfg)
+				})
+				im.Canvas("Canvas", f32.Point{}, func(c *imgio.Canvas) {
+					c.Persist = true
+					dl := c.DrawList()
+					for k := range 5 {
+						p := c.ToScreen(f32.Pt(float32(k)*64, float32(k%2)*64))
+						dl.AddCircleFilled(p, 16*c.View.Zoom, imgio.GetTheme().ContrastBg)
+					}
+				})
+				nodes.BeginNodeEditor(im, "Graph", func(e *nodes.Editor) {
+					e.Node("source", "Source", func(n *nodes.Node) {
+						n.Output("out", "Signal")
+					})
+					e.Node("gain", "Gain", func(n *nodes.Node) {
+						n.Input("in", "In")
+						n.Output("out", "Out")
+						n.Im.SliderFloat("Gain", &gain, 0, 2)
+					})
+					e.Node("sink", "Output", func(n *nodes.Node) {
+						n.Input("in", "In")
+					})
+				})
+				if showPlot {
+					plot.BeginPlot(im, "Plot", func(p *plot.Plot) {
+						p.PlotLine("sin", xs, ys)
+						p.PlotScatter("points", xs, ys)
+					})
+				}
 			})
 			imgio.ThemeEdit(&win_open)
+			imgio.Layouts(&layouts_open)
+			imgio.Tweaks(&tweaks_open)
+			imgio.Render(gtx)
+			layout.S.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return imgio.Panel(gtx, "toolbar", func(im *imgio.Im) {
+					im.SetAxis(layout.Horizontal)
+					if im.Button("Show windows") {
+						win_open = true
+					}
+					if im.Button("Layouts") {
+						layouts_open = true
+					}
+					if im.Button("Tweaks") {
+						tweaks_open = true
+					}
+					if im.Button("Undo") {
+						imgio.Undo()
+					}
+					if im.Button("Redo") {
+						imgio.Redo()
+					}
+					im.Text("%d samples", samples.Len())
+				})
+			})
 
 			e.Frame(gtx.Ops)
 
 		// this is sent when the application is closed.
 		case app.DestroyEvent:
-			imgio.DestroyEvent()
+			if err := imgio.DestroyEvent(); err != nil {
+				log.Println(err)
+			}
 			return e.Err
 		}
 	}
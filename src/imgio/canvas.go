@@ -0,0 +1,231 @@
+package imgio
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// CanvasView is the pan and zoom of a Canvas.  World point p is drawn at
+// p*Zoom + Offset, in pixels from the canvas' top left.
+type CanvasView struct {
+	Offset f32.Point
+	Zoom   float32
+}
+
+// Canvas is a pannable, zoomable area drawn through world coordinates.  Dragging with
+// PanButtons, the middle button by default, pans and the mouse wheel zooms about the
+// pointer.  The Canvas persists between frames, so options can be set once or every frame.
+type Canvas struct {
+	View CanvasView
+	// GridStep is the world distance between grid lines, zero hides the grid
+	GridStep float32
+	// GridMajor draws every GridMajor'th grid line stronger
+	GridMajor int
+	GridColor color.NRGBA
+	// MinZoom and MaxZoom limit the zoom
+	MinZoom, MaxZoom float32
+	// Persist saves the view with the window.  A saved view is restored before body
+	// first runs.
+	Persist bool
+	// PanButtons are the buttons that pan when dragged
+	PanButtons pointer.Buttons
+
+	size     image.Point
+	drawList DrawList
+	panning  bool
+	last     f32.Point
+	hovered  bool
+	pointer  f32.Point
+	ctx      *Context
+}
+
+// Canvas adds a canvas of size dp to the window.  A zero width fills the line and a zero
+// height is 200dp.  body draws through c.DrawList, converting with ToScreen.
+func (i *Im) Canvas(id string, size f32.Point, body func(c *Canvas)) {
+	_, id = getId(id, "canvas")
+	c := fromCache(i, id, func() *Canvas {
+		c := &Canvas{
+			View:       CanvasView{Zoom: 1},
+			GridStep:   32,
+			GridMajor:  8,
+			GridColor:  i.ctx.theme.ContrastBg,
+			MinZoom:    0.05,
+			MaxZoom:    20,
+			PanButtons: pointer.ButtonTertiary,
+			ctx:        i.ctx,
+		}
+		// body sets Persist, so the view is restored before knowing whether it is wanted
+		i.restore(id, &c.View)
+		return c
+	})
+	c.drawList.Reset()
+	c.drawList.theme = i.theme
+	body(c)
+	if c.Persist {
+		i.keepPersisted(id, &c.View)
+	}
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		return c.layout(gtx, size)
+	})
+}
+
+// ToScreen converts a world position to pixels from the canvas' top left
+func (c *Canvas) ToScreen(p f32.Point) f32.Point {
+	return p.Mul(c.View.Zoom).Add(c.View.Offset)
+}
+
+// ToWorld converts pixels from the canvas' top left to a world position
+func (c *Canvas) ToWorld(p f32.Point) f32.Point {
+	return p.Sub(c.View.Offset).Div(c.View.Zoom)
+}
+
+// Size is the canvas size in pixels as of the last layout
+func (c *Canvas) Size() f32.Point {
+	return layout.FPt(c.size)
+}
+
+// Hovered reports whether the pointer is over the canvas
+func (c *Canvas) Hovered() bool {
+	return c.hovered
+}
+
+// MousePos is the world position of the pointer
+func (c *Canvas) MousePos() f32.Point {
+	return c.ToWorld(c.pointer)
+}
+
+// DrawList returns the list drawn over the grid, in pixels from the canvas' top left
+func (c *Canvas) DrawList() *DrawList {
+	return &c.drawList
+}
+
+// CenterOn pans so that world position p is in the middle of the canvas
+func (c *Canvas) CenterOn(p f32.Point) {
+	c.View.Offset = c.Size().Mul(0.5).Sub(p.Mul(c.View.Zoom))
+}
+
+// ZoomAt sets the zoom, keeping the world position under screen position at stays put
+func (c *Canvas) ZoomAt(at f32.Point, zoom float32) {
+	if c.MaxZoom > 0 {
+		zoom = min(zoom, c.MaxZoom)
+	}
+	zoom = max(zoom, c.MinZoom, 1e-6)
+	world := c.ToWorld(at)
+	c.View.Zoom = zoom
+	c.View.Offset = at.Sub(world.Mul(zoom))
+}
+
+func (c *Canvas) update(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target:  c,
+			Kinds:   pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel | pointer.Scroll | pointer.Move | pointer.Enter | pointer.Leave,
+			ScrollY: pointer.ScrollRange{Min: math.MinInt32, Max: math.MaxInt32},
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case pointer.Press:
+			if e.Buttons&c.PanButtons != 0 {
+				gtx.Execute(pointer.GrabCmd{Tag: c, ID: e.PointerID})
+				c.panning = true
+				c.last = e.Position
+			}
+		case pointer.Drag:
+			if c.panning {
+				c.View.Offset = c.View.Offset.Add(e.Position.Sub(c.last))
+				c.last = e.Position
+			}
+		case pointer.Release, pointer.Cancel:
+			c.panning = false
+		case pointer.Scroll:
+			factor := float32(0.9)
+			if e.Scroll.Y < 0 {
+				factor = 1 / factor
+			}
+			c.ZoomAt(e.Position, c.View.Zoom*factor)
+		case pointer.Leave:
+			c.hovered = false
+		}
+		if e.Kind != pointer.Leave {
+			c.hovered = true
+		}
+		c.pointer = e.Position
+		c.ctx.Invalidate()
+	}
+}
+
+func (c *Canvas) layout(gtx layout.Context, size f32.Point) layout.Dimensions {
+	c.update(gtx)
+
+	sz := image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y)))
+	if sz.X <= 0 {
+		sz.X = gtx.Constraints.Max.X
+	}
+	if sz.Y <= 0 {
+		sz.Y = gtx.Dp(200)
+	}
+	sz = gtx.Constraints.Constrain(sz)
+	c.size = sz
+	defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, c)
+	bg := c.ctx.theme.ContrastBg
+	bg.A = 0x10
+	paint.Fill(gtx.Ops, bg)
+	c.drawGrid(gtx)
+	c.drawList.Layout(gtx)
+	return layout.Dimensions{Size: sz}
+}
+
+func (c *Canvas) drawGrid(gtx layout.Context) {
+	if c.GridStep <= 0 {
+		return
+	}
+	// skip lines rather than draw a solid wash when zoomed out
+	step, every := c.GridStep, 1
+	for step*float32(every)*c.View.Zoom < float32(gtx.Dp(6)) {
+		every *= 2
+	}
+	minor, major := c.GridColor, c.GridColor
+	minor.A /= 4
+	major.A /= 2
+	tl, br := c.ToWorld(f32.Point{}), c.ToWorld(c.Size())
+	lines := func(from, to float32, line func(v float32, col color.NRGBA)) {
+		first := int(math.Floor(float64(from / step)))
+		last := int(math.Ceil(float64(to / step)))
+		first -= ((first % every) + every) % every
+		for k := first; k <= last; k += every {
+			col := minor
+			switch {
+			case k == 0:
+				col = c.GridColor
+			case c.GridMajor > 1 && k%c.GridMajor == 0:
+				col = major
+			}
+			line(float32(k)*step, col)
+		}
+	}
+	w, h := c.size.X, c.size.Y
+	lines(tl.X, br.X, func(v float32, col color.NRGBA) {
+		x := int(c.ToScreen(f32.Pt(v, 0)).X)
+		fillRect(gtx.Ops, image.Rect(x, 0, x+1, h), col)
+	})
+	lines(tl.Y, br.Y, func(v float32, col color.NRGBA) {
+		y := int(c.ToScreen(f32.Pt(0, v)).Y)
+		fillRect(gtx.Ops, image.Rect(0, y, w, y+1), col)
+	})
+}
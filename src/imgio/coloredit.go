@@ -4,79 +4,137 @@ import (
 	"image"
 	"image/color"
 
+	"gioui.org/gesture"
 	"gioui.org/layout"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 )
 
+type colorEditf3Context struct {
+	col *[3]float64
+	w   layout.Widget
+}
+
 // returns true if the values changed
 func (im *Im) ColorEditf3(label string, col *[3]float64) bool {
 	label, id := getId(label, "coloreditf3")
-	w := fromCache(im, id, func() layout.Widget {
-		return func(gtx layout.Context) layout.Dimensions {
-			ops := im.gtx.Ops
-			defer clip.Rect(image.Rect(0, 0, 40, 40)).Push(ops).Pop()
-			u8 := func(f float64) uint8 { return uint8(f * 255) }
-			r, g, b := u8(col[0]), u8(col[1]), u8(col[2])
-			paint.ColorOp{Color: color.NRGBA{R: r, G: g, B: b, A: 0xFF}}.Add(ops)
-			paint.PaintOp{}.Add(ops)
+	im.persistWidget(id, col)
+	c := fromCache(im, id, func() *colorEditf3Context {
+		c := &colorEditf3Context{}
+		c.w = func(gtx layout.Context) layout.Dimensions {
+			defer clip.Rect(image.Rect(0, 0, 40, 40)).Push(gtx.Ops).Pop()
+			paint.ColorOp{Color: floatsToNRGBA([4]float64{c.col[0], c.col[1], c.col[2], 1})}.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
 			return layout.Dimensions{Size: image.Pt(40, 40)}
 		}
+		return c
 	})
+	c.col = col
 
-	im.SliderFloat("R", &col[0], 0, 1)
-	im.SliderFloat("G", &col[1], 0, 1)
-	im.SliderFloat("B", &col[2], 0, 1)
+	changed := false
+	for k, name := range rgbaComponentNames[:3] {
+		// the sliders are bound to col each frame, so they follow changes made by the caller
+		changed = im.SliderFloat(name+"##"+id, &col[k], 0, 1) || changed
+	}
 	im.Text(label)
-	im.AddWidget(w)
+	im.AddWidget(c.w)
 
-	return true
+	return changed
 }
 
 type colorEditContext struct {
-	r int64
-	g int64
-	b int64
-	a int64
-	w layout.Widget
+	rgba    [4]int64
+	last    color.NRGBA // the color most recently exchanged with the caller
+	changed bool
+	click   gesture.Click
+	popup   popup
+	picker  colorPicker
+	w       layout.Widget
+}
+
+var rgbaComponentNames = []string{"R", "G", "B", "A"}
+
+func nrgbaToInts(c color.NRGBA) [4]int64 {
+	return [4]int64{int64(c.R), int64(c.G), int64(c.B), int64(c.A)}
 }
 
 // returns true if the values changed
 func (im *Im) ColorEdit(label string, col *color.NRGBA) bool {
 	label, id := getId(label, "coloredit")
+	im.persistWidget(id, col)
 	c := fromCache(im, id, func() *colorEditContext {
 		ret := &colorEditContext{
-			r: int64(col.R),
-			g: int64(col.G),
-			b: int64(col.B),
-			a: int64(col.A),
+			rgba:  nrgbaToInts(*col),
+			last:  *col,
+			popup: popup{ui: im.ctx},
 		}
 		ret.w = func(gtx layout.Context) layout.Dimensions {
-			h := LineHeight(gtx)
+			h := im.ctx.LineHeight(gtx)
 			gtx.Constraints.Min.Y = h
-			ops := im.gtx.Ops
-			defer clip.Rect(image.Rect(0, 0, h, h)).Push(ops).Pop()
-			r, g, b, a := uint8(ret.r), uint8(ret.g), uint8(ret.b), uint8(ret.a)
-			*col = color.NRGBA{R: r, G: g, B: b, A: a}
-			paint.ColorOp{Color: *col}.Add(ops)
-			paint.PaintOp{}.Add(ops)
+			for {
+				e, ok := ret.click.Update(gtx.Source)
+				if !ok {
+					break
+				}
+				if e.Kind == gesture.KindClick {
+					ret.popup.open = true
+					im.ctx.Invalidate()
+				}
+			}
+			func() {
+				defer clip.Rect(image.Rect(0, 0, h, h)).Push(gtx.Ops).Pop()
+				paint.ColorOp{Color: ret.last}.Add(gtx.Ops)
+				paint.PaintOp{}.Add(gtx.Ops)
+				ret.click.Add(gtx.Ops)
+			}()
+			ret.popup.Layout(gtx, image.Pt(0, h), func(pim *Im) {
+				ret.picker.alpha = true
+				ret.picker.bind(nrgbaToFloats(ret.last), func(rgba [4]float64) [4]float64 {
+					ret.last = floatsToNRGBA(rgba)
+					ret.rgba = nrgbaToInts(ret.last)
+					ret.changed = true
+					*col = ret.last
+					return nrgbaToFloats(ret.last)
+				})
+				pim.colorPicker(id+"/picker", &ret.picker)
+			})
 			return layout.Dimensions{Size: image.Pt(h, h)}
 		}
 		return ret
 	})
 
+	switch {
+	case *col != c.last:
+		// changed by the caller, which wins over any edit in flight
+		c.last = *col
+		c.rgba = nrgbaToInts(*col)
+	case c.rgba != nrgbaToInts(c.last):
+		// edited through the drags during the last layout
+		v := c.rgba
+		c.last = color.NRGBA{R: uint8(v[0]), G: uint8(v[1]), B: uint8(v[2]), A: uint8(v[3])}
+		*col = c.last
+		c.changed = true
+	}
+	changed := c.changed
+	c.changed = false
+	// the popup and the component drags each edit until they're let go
+	active := c.popup.open
+	for _, d := range fromCache(im, id+"/rgba", func() *dragVecCtx[int64] {
+		return &dragVecCtx[int64]{}
+	}).drags {
+		active = active || d.active()
+	}
+	recordEdit(im, label, id, col, active, changed)
+
 	im.WithSameLine(func(im *Im) {
 		im.WithFlexMode(FlexModeRigid, func(im *Im) {
-			im.WithMinConstraints(layout.Constraints{Min: image.Pt(120, LineHeight(im.gtx))}, func(im *Im) {
-				im.DragInt("G##"+label, &c.g, 1, 0, 255, "G:%d")
-				im.DragInt("B##"+label, &c.b, 1, 0, 255, "B:%d")
-				im.DragInt("R##"+label, &c.r, 1, 0, 255, "R:%d")
-				im.DragInt("A##"+label, &c.a, 1, 0, 255, "A:%d")
+			im.WithMinConstraints(layout.Constraints{Min: image.Pt(120, im.ctx.LineHeight(im.gtx))}, func(im *Im) {
+				dragVecComponents(im, id+"/rgba", c.rgba[:], rgbaComponentNames, 1, 0, 255, "%d")
 				im.AddWidget(c.w)
 			})
 		})
 		im.Text(label)
 	})
 
-	return true
+	return changed
 }
@@ -0,0 +1,447 @@
+package imgio
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"gioui.org/f32"
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+type colorPickerMode uint8
+
+const (
+	pickerRGB colorPickerMode = iota
+	pickerHSV
+	pickerHex
+)
+
+var (
+	pickerModeNames   = []string{"RGB", "HSV", "Hex"}
+	hsvComponentNames = []string{"H", "S", "V", "A"}
+	// hue is shown in degrees, the rest like the rgb inputs
+	hsvComponentScale = [4]float64{360, 255, 255, 255}
+)
+
+// colorPicker holds the state behind ColorPicker3/4 and the ColorEdit popup.  The
+// color is kept as HSV so the hue survives desaturating to grey or darkening to black.
+type colorPicker struct {
+	ui      *Context
+	hsva    [4]float64
+	last    [4]float64 // the rgba most recently exchanged with the caller
+	set     func(rgba [4]float64) [4]float64
+	alpha   bool
+	changed bool
+	mode    colorPickerMode
+
+	// the values shown in the rgb/hsv/hex inputs, and what they held after the last refresh
+	rgba      [4]int64
+	hsv       [4]int64
+	hex       string
+	shownRGBA [4]int64
+	shownHSV  [4]int64
+	shownHex  string
+
+	sv       gesture.Drag
+	hue      gesture.Drag
+	alphaBar gesture.Drag
+}
+
+// returns true if the color changed
+func (im *Im) ColorPicker3(label string, col *[3]float64) bool {
+	label, id := getId(label, "colorpicker3")
+	im.persistWidget(id, col)
+	p := fromCache(im, id, func() *colorPicker {
+		return &colorPicker{}
+	})
+	p.bind([4]float64{col[0], col[1], col[2], 1}, func(rgba [4]float64) [4]float64 {
+		copy(col[:], rgba[:3])
+		return [4]float64{col[0], col[1], col[2], 1}
+	})
+	changed := im.colorPicker(id, p)
+	im.Text(label)
+	return changed
+}
+
+// returns true if the color changed
+func (im *Im) ColorPicker4(label string, col *[4]float64) bool {
+	label, id := getId(label, "colorpicker4")
+	im.persistWidget(id, col)
+	p := fromCache(im, id, func() *colorPicker {
+		return &colorPicker{alpha: true}
+	})
+	p.bind(*col, func(rgba [4]float64) [4]float64 {
+		*col = rgba
+		return *col
+	})
+	changed := im.colorPicker(id, p)
+	im.Text(label)
+	return changed
+}
+
+// bind attaches the picker to the caller's color for this frame.  set stores a new
+// color and returns what was actually stored, after any rounding.
+func (p *colorPicker) bind(rgba [4]float64, set func(rgba [4]float64) [4]float64) {
+	p.set = set
+	if rgba != p.last {
+		// changed by the caller
+		p.last = rgba
+		p.setRGBA(rgba)
+	}
+}
+
+// colorPicker adds the picker widgets to im.  returns true if the color changed
+func (im *Im) colorPicker(id string, p *colorPicker) bool {
+	p.ui = im.ctx
+	p.applyEdits()
+	changed := p.changed
+	p.changed = false
+	n := 3
+	if p.alpha {
+		n = 4
+	}
+	im.AddWidget(p.layout)
+	im.WithSameLine(func(im *Im) {
+		switch p.mode {
+		case pickerRGB:
+			dragVecComponents(im, id+"/rgb", p.rgba[:n], rgbaComponentNames, 1, 0, 255, "%d")
+		case pickerHSV:
+			dragVecComponents(im, id+"/hue", p.hsv[:1], hsvComponentNames[:1], 1, 0, 360, "%d")
+			dragVecComponents(im, id+"/hsv", p.hsv[1:n], hsvComponentNames[1:], 1, 0, 255, "%d")
+		case pickerHex:
+			im.InputText("##"+id+"/hex", &p.hex)
+		}
+		im.WithFlexMode(FlexModeRigid, func(im *Im) {
+			if im.Button(pickerModeNames[p.mode] + "###" + id + "/mode") {
+				p.mode = (p.mode + 1) % colorPickerMode(len(pickerModeNames))
+			}
+		})
+	})
+	return changed
+}
+
+// applyEdits picks up changes made through the rgb, hsv and hex inputs since the last frame
+func (p *colorPicker) applyEdits() {
+	switch {
+	case p.rgba != p.shownRGBA:
+		rgba := p.rgbaF()
+		for k := range rgba {
+			if k < 3 || p.alpha {
+				rgba[k] = float64(p.rgba[k]) / 255
+			}
+		}
+		p.setRGBA(rgba)
+		p.write()
+	case p.hsv != p.shownHSV:
+		for k := range p.hsv {
+			if k < 3 || p.alpha {
+				p.hsva[k] = float64(p.hsv[k]) / hsvComponentScale[k]
+			}
+		}
+		p.write()
+	case p.hex != p.shownHex:
+		if c, ok := parseHexColor(p.hex); ok {
+			if !p.alpha {
+				c.A = 0xff
+			}
+			p.setRGBA(nrgbaToFloats(c))
+			p.write()
+			// keep the text as typed
+			p.shownHex = p.hex
+		}
+	}
+	p.refresh()
+}
+
+// refresh updates the rgb, hsv and hex inputs from the current color
+func (p *colorPicker) refresh() {
+	c := floatsToNRGBA(p.rgbaF())
+	p.rgba = [4]int64{int64(c.R), int64(c.G), int64(c.B), int64(c.A)}
+	for k := range p.hsv {
+		p.hsv[k] = int64(math.Round(p.hsva[k] * hsvComponentScale[k]))
+	}
+	p.shownRGBA, p.shownHSV = p.rgba, p.hsv
+	// leave a partially typed hex string alone until the color changes from elsewhere
+	if shown, ok := parseHexColor(p.shownHex); !ok || shown != c || p.hex == p.shownHex {
+		p.hex = formatHexColor(c, p.alpha)
+		p.shownHex = p.hex
+	}
+}
+
+func (p *colorPicker) rgbaF() [4]float64 {
+	r, g, b := hsvToRGB(p.hsva[0], p.hsva[1], p.hsva[2])
+	return [4]float64{r, g, b, p.hsva[3]}
+}
+
+func (p *colorPicker) setRGBA(rgba [4]float64) {
+	h, s, v := rgbToHSV(rgba[0], rgba[1], rgba[2])
+	if v == 0 {
+		// black has no hue or saturation
+		h, s = p.hsva[0], p.hsva[1]
+	} else if s == 0 {
+		h = p.hsva[0]
+	}
+	p.hsva = [4]float64{h, s, v, rgba[3]}
+}
+
+// write sends the current color to the caller
+func (p *colorPicker) write() {
+	p.last = p.set(p.rgbaF())
+	p.changed = true
+	p.ui.Invalidate()
+}
+
+func (p *colorPicker) layout(gtx layout.Context) layout.Dimensions {
+	size := gtx.Dp(150)
+	bar := gtx.Dp(20)
+	gap := layout.Spacer{Width: 6}
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return p.layoutSV(gtx, size)
+		}),
+		layout.Rigid(gap.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return p.layoutHue(gtx, bar, size)
+		}),
+	}
+	if p.alpha {
+		children = append(children,
+			layout.Rigid(gap.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return p.layoutAlpha(gtx, bar, size)
+			}),
+		)
+	}
+	children = append(children,
+		layout.Rigid(gap.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			r := image.Rect(0, 0, 2*bar, 2*bar)
+			drawCheckerboard(gtx.Ops, r, gtx.Dp(5))
+			fillRect(gtx.Ops, r, floatsToNRGBA(p.rgbaF()))
+			return layout.Dimensions{Size: r.Max}
+		}),
+	)
+	return layout.Flex{}.Layout(gtx, children...)
+}
+
+// dragPos returns the latest pointer position of a press or drag on d
+func dragPos(gtx layout.Context, d *gesture.Drag) (f32.Point, bool) {
+	var pos f32.Point
+	moved := false
+	for {
+		e, ok := d.Update(gtx.Metric, gtx.Source, gesture.Both)
+		if !ok {
+			break
+		}
+		if e.Kind == pointer.Press || e.Kind == pointer.Drag {
+			pos, moved = e.Position, true
+		}
+	}
+	return pos, moved
+}
+
+// layoutSV draws the saturation/value square for the current hue
+func (p *colorPicker) layoutSV(gtx layout.Context, size int) layout.Dimensions {
+	if pos, ok := dragPos(gtx, &p.sv); ok {
+		p.hsva[1] = clamp(float64(pos.X)/float64(size), 0, 1)
+		p.hsva[2] = 1 - clamp(float64(pos.Y)/float64(size), 0, 1)
+		p.write()
+	}
+	r := image.Rect(0, 0, size, size)
+	func() {
+		defer clip.Rect(r).Push(gtx.Ops).Pop()
+		hr, hg, hb := hsvToRGB(p.hsva[0], 1, 1)
+		paint.Fill(gtx.Ops, floatsToNRGBA([4]float64{hr, hg, hb, 1}))
+		paint.LinearGradientOp{
+			Stop1:  f32.Pt(0, 0),
+			Color1: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+			Stop2:  f32.Pt(float32(size), 0),
+			Color2: color.NRGBA{R: 0xff, G: 0xff, B: 0xff},
+		}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		paint.LinearGradientOp{
+			Stop1:  f32.Pt(0, 0),
+			Color1: color.NRGBA{},
+			Stop2:  f32.Pt(0, float32(size)),
+			Color2: color.NRGBA{A: 0xff},
+		}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		p.sv.Add(gtx.Ops)
+	}()
+	// marker
+	c := image.Pt(int(p.hsva[1]*float64(size)), int((1-p.hsva[2])*float64(size)))
+	rad := gtx.Dp(4)
+	marker := clip.Ellipse{Min: c.Sub(image.Pt(rad, rad)), Max: c.Add(image.Pt(rad, rad))}
+	paint.FillShape(gtx.Ops, color.NRGBA{A: 0xff}, clip.Stroke{Path: marker.Path(gtx.Ops), Width: 3}.Op())
+	paint.FillShape(gtx.Ops, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, clip.Stroke{Path: marker.Path(gtx.Ops), Width: 1}.Op())
+	return layout.Dimensions{Size: r.Max}
+}
+
+// layoutHue draws the vertical hue bar
+func (p *colorPicker) layoutHue(gtx layout.Context, width, height int) layout.Dimensions {
+	if pos, ok := dragPos(gtx, &p.hue); ok {
+		// keep the hue below 1 so red stays at the top of the bar
+		p.hsva[0] = clamp(float64(pos.Y)/float64(height), 0, 0.9999)
+		p.write()
+	}
+	r := image.Rect(0, 0, width, height)
+	func() {
+		defer clip.Rect(r).Push(gtx.Ops).Pop()
+		for k := 0; k < 6; k++ {
+			y0, y1 := height*k/6, height*(k+1)/6
+			r0, g0, b0 := hsvToRGB(float64(k)/6, 1, 1)
+			r1, g1, b1 := hsvToRGB(float64(k+1)/6, 1, 1)
+			func() {
+				defer clip.Rect(image.Rect(0, y0, width, y1)).Push(gtx.Ops).Pop()
+				paint.LinearGradientOp{
+					Stop1:  f32.Pt(0, float32(y0)),
+					Color1: floatsToNRGBA([4]float64{r0, g0, b0, 1}),
+					Stop2:  f32.Pt(0, float32(y1)),
+					Color2: floatsToNRGBA([4]float64{r1, g1, b1, 1}),
+				}.Add(gtx.Ops)
+				paint.PaintOp{}.Add(gtx.Ops)
+			}()
+		}
+		p.hue.Add(gtx.Ops)
+	}()
+	drawBarMarker(gtx.Ops, width, int(p.hsva[0]*float64(height)))
+	return layout.Dimensions{Size: r.Max}
+}
+
+// layoutAlpha draws the vertical alpha bar over a checkerboard, opaque at the top
+func (p *colorPicker) layoutAlpha(gtx layout.Context, width, height int) layout.Dimensions {
+	if pos, ok := dragPos(gtx, &p.alphaBar); ok {
+		p.hsva[3] = 1 - clamp(float64(pos.Y)/float64(height), 0, 1)
+		p.write()
+	}
+	r := image.Rect(0, 0, width, height)
+	func() {
+		defer clip.Rect(r).Push(gtx.Ops).Pop()
+		drawCheckerboard(gtx.Ops, r, width/2)
+		opaque := floatsToNRGBA(p.rgbaF())
+		opaque.A = 0xff
+		clear := opaque
+		clear.A = 0
+		paint.LinearGradientOp{
+			Stop1:  f32.Pt(0, 0),
+			Color1: opaque,
+			Stop2:  f32.Pt(0, float32(height)),
+			Color2: clear,
+		}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		p.alphaBar.Add(gtx.Ops)
+	}()
+	drawBarMarker(gtx.Ops, width, int((1-p.hsva[3])*float64(height)))
+	return layout.Dimensions{Size: r.Max}
+}
+
+func drawBarMarker(ops *op.Ops, width, y int) {
+	fillRect(ops, image.Rect(0, y-2, width, y+2), color.NRGBA{A: 0xff})
+	fillRect(ops, image.Rect(0, y-1, width, y+1), color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+}
+
+func fillRect(ops *op.Ops, r image.Rectangle, c color.NRGBA) {
+	paint.FillShape(ops, c, clip.Rect(r).Op())
+}
+
+// drawCheckerboard fills r with the grey checks used behind translucent colors
+func drawCheckerboard(ops *op.Ops, r image.Rectangle, cell int) {
+	cell = max(cell, 1)
+	fillRect(ops, r, color.NRGBA{R: 0xcc, G: 0xcc, B: 0xcc, A: 0xff})
+	dark := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	for y := r.Min.Y; y < r.Max.Y; y += cell {
+		for x := r.Min.X; x < r.Max.X; x += cell {
+			if ((x-r.Min.X)/cell+(y-r.Min.Y)/cell)%2 == 1 {
+				fillRect(ops, image.Rect(x, y, min(x+cell, r.Max.X), min(y+cell, r.Max.Y)), dark)
+			}
+		}
+	}
+}
+
+// hsvToRGB converts hue, saturation and value in [0, 1] to rgb in [0, 1]
+func hsvToRGB(h, s, v float64) (r, g, b float64) {
+	h = math.Mod(h, 1) * 6
+	i := math.Floor(h)
+	f := h - i
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+	switch int(i) {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	}
+	return v, p, q
+}
+
+// rgbToHSV converts rgb in [0, 1] to hue, saturation and value in [0, 1]
+func rgbToHSV(r, g, b float64) (h, s, v float64) {
+	v = max(r, g, b)
+	d := v - min(r, g, b)
+	if v > 0 {
+		s = d / v
+	}
+	if d == 0 {
+		return 0, s, v
+	}
+	switch v {
+	case r:
+		h = (g - b) / d
+	case g:
+		h = 2 + (b-r)/d
+	default:
+		h = 4 + (r-g)/d
+	}
+	h /= 6
+	if h < 0 {
+		h++
+	}
+	return h, s, v
+}
+
+func nrgbaToFloats(c color.NRGBA) [4]float64 {
+	return [4]float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, float64(c.A) / 255}
+}
+
+func floatsToNRGBA(f [4]float64) color.NRGBA {
+	u8 := func(f float64) uint8 { return uint8(math.Round(clamp(f, 0, 1) * 255)) }
+	return color.NRGBA{R: u8(f[0]), G: u8(f[1]), B: u8(f[2]), A: u8(f[3])}
+}
+
+// formatHexColor returns c as #RRGGBB, or #RRGGBBAA when alpha is true
+func formatHexColor(c color.NRGBA, alpha bool) string {
+	if alpha {
+		return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+	}
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// parseHexColor parses #RRGGBB or #RRGGBBAA, the # is optional
+func parseHexColor(s string) (color.NRGBA, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 && len(s) != 8 {
+		return color.NRGBA{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	if len(s) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+}
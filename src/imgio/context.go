@@ -0,0 +1,395 @@
+package imgio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+)
+
+// Context is one independent imgio UI.  It owns the windows, theme, saved state and
+// draw lists, so several can share a Gio window without trampling each other.
+type Context struct {
+	gtx        layout.Context
+	wm         *WindowManager
+	windows    map[string]*Window
+	panels     map[string]*Im
+	state      SavedState
+	theme      *material.Theme
+	imTheme    Theme
+	app        App
+	background DrawList
+	foreground DrawList
+	// order holds every window, from the back to the front
+	order []*Window
+	// visible holds the windows begun this frame, in drawing order
+	visible []*Window
+	inFrame bool
+	// immediate is set for frames started by the deprecated SetContext, whose windows
+	// are drawn as soon as they are begun
+	immediate bool
+
+	storage Storage
+	// saved holds what was last loaded or saved by key, so autosave can skip unchanged
+	// state
+	saved       map[string][]byte
+	autosave    time.Duration
+	lastSave    time.Time
+	onSaveError func(err error)
+	// saveTimer asks for a frame when the autosave interval is up.  unsaved is set when
+	// a frame has run since the last save.
+	saveTimer *time.Timer
+	unsaved   bool
+	// newerState is set when the saved state came from a newer format, which isn't
+	// saved over
+	newerState bool
+
+	undo undoStack
+
+	// ThemeEdit keeps float copies of the insets while it edits them
+	themeEditOnce sync.Once
+	buttonInset   *shadowInset
+	widgetInset   *shadowInset
+}
+
+// NewContext creates a Context that asks a for new frames, loading its saved state and
+// theme from the working directory.  Errors loading them are ignored, use
+// NewContextWithStorage to see them.
+func NewContext(a App) *Context {
+	ctx, _ := NewContextWithStorage(a, NewFileStorage("."))
+	return ctx
+}
+
+// NewContextWithStorage creates a Context that asks a for new frames and keeps its saved
+// state and theme in s.  The Context is usable even when loading fails, starting from
+// the defaults for whatever couldn't be loaded.
+func NewContextWithStorage(a App, s Storage) (*Context, error) {
+	ctx := newContext(a, s, newTheme())
+	err := errors.Join(
+		ctx.loadState(),
+		ctx.load(themeFileName, &ctx.imTheme),
+	)
+	return ctx, err
+}
+
+// newContext creates a Context drawing with th, without loading anything from s
+func newContext(a App, s Storage, th *material.Theme) *Context {
+	ctx := &Context{
+		wm:       &WindowManager{},
+		windows:  map[string]*Window{},
+		panels:   map[string]*Im{},
+		state:    newSavedState(),
+		app:      a,
+		storage:  s,
+		saved:    map[string][]byte{},
+		lastSave: time.Now(),
+	}
+	ctx.theme = th
+	ctx.imTheme.Palette = &ctx.theme.Palette
+	ctx.background.theme = ctx.theme
+	ctx.foreground.theme = ctx.theme
+	ctx.imTheme.DragFastMultiplier = 10
+	ctx.imTheme.DragSlowMultiplier = 0.1
+	return ctx
+}
+
+// newTheme returns the theme contexts start with
+func newTheme() *material.Theme {
+	th := material.NewTheme()
+	th.Face = "monospace"
+	return th
+}
+
+// load unmarshals the value saved under key into v.  A key that was never saved is not
+// an error.
+func (ctx *Context) load(key string, v any) error {
+	data, err := ctx.storage.Load(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("imgio: loading %s: %w", key, err)
+	}
+	ctx.saved[key] = data
+	return nil
+}
+
+// Theme returns the material theme shared by the context's widgets
+func (ctx *Context) Theme() *material.Theme {
+	return ctx.theme
+}
+
+// Invalidate requests another frame from the context's App
+func (ctx *Context) Invalidate() {
+	if ctx.app != nil {
+		ctx.app.Invalidate()
+	}
+}
+
+// NewIm creates an Im that draws with the context's theme
+func (ctx *Context) NewIm() *Im {
+	return newIm(ctx, ctx.theme)
+}
+
+// NewFrame starts a frame, handling the input that moves and resizes windows.  Windows
+// added with Begin are drawn by Render, which ends the frame.
+func (ctx *Context) NewFrame(gtx layout.Context) {
+	if ctx.inFrame {
+		panic("imgio: NewFrame called before the previous frame was rendered")
+	}
+	ctx.inFrame = true
+	ctx.immediate = false
+	ctx.unsaved = true
+	ctx.gtx = gtx
+	ctx.wm.Layout(gtx)
+	ctx.restoreTweaks()
+	ctx.handleUndoKeys(gtx)
+}
+
+// EndFrame stops accepting windows for the frame started by NewFrame.  Render calls it
+// when needed, so it is only required when the frame is drawn some time later.
+func (ctx *Context) EndFrame() {
+	if !ctx.inFrame {
+		return
+	}
+	ctx.inFrame = false
+	ctx.immediate = false
+	// windows are drawn bottom to top, in the order they were last raised
+	ctx.visible = ctx.visible[:0]
+	for _, win := range ctx.order {
+		if win.begun {
+			ctx.visible = append(ctx.visible, win)
+			win.begun = false
+		}
+	}
+}
+
+// Render ends the frame and draws it: the background draw list, the windows from the
+// back to the front, and then the foreground draw list.  Popups and tooltips are
+// deferred, so they are drawn over everything.
+func (ctx *Context) Render(gtx layout.Context) {
+	ctx.EndFrame()
+	ctx.background.Layout(gtx)
+	for _, win := range ctx.visible {
+		win.Layout(gtx, win.im.Layout)
+	}
+	ctx.foreground.Layout(gtx)
+	ctx.finishFrame()
+}
+
+// immediateFrame starts a frame for the deprecated SetContext.  Its callers never end
+// their frames, so one still open is finished first, or dropped when it was started by
+// NewFrame and never rendered.  Shapes added to the draw lists after that frame's last
+// window are dropped, its ops are long gone.
+func (ctx *Context) immediateFrame(gtx layout.Context) {
+	if ctx.inFrame {
+		ctx.endImmediateFrame()
+	}
+	ctx.NewFrame(gtx)
+	ctx.immediate = true
+}
+
+// endImmediateFrame ends the current frame without drawing anything more
+func (ctx *Context) endImmediateFrame() {
+	ctx.EndFrame()
+	ctx.visible = ctx.visible[:0]
+	ctx.finishFrame()
+}
+
+// flushDrawLists draws the shapes added to the draw lists so far in an immediate frame.
+// The background goes beneath whatever is drawn next, and the foreground is deferred
+// so it is drawn over all the windows.
+func (ctx *Context) flushDrawLists(gtx layout.Context) {
+	ctx.background.Layout(gtx)
+	ctx.background.Reset()
+	if len(ctx.foreground.cmds) > 0 {
+		m := op.Record(gtx.Ops)
+		ctx.foreground.Layout(gtx)
+		op.Defer(gtx.Ops, m.Stop())
+		ctx.foreground.Reset()
+	}
+}
+
+// finishFrame empties the draw lists, autosaves, and brings the window pressed this
+// frame to the front
+func (ctx *Context) finishFrame() {
+	ctx.background.Reset()
+	ctx.foreground.Reset()
+	ctx.maybeAutosave()
+	ctx.scheduleAutosave()
+
+	// a window pressed this frame moves to the front for the next one
+	for k := 0; k < len(ctx.order); k++ {
+		if win := ctx.order[k]; win.raised {
+			win.raised = false
+			copy(ctx.order[k:], ctx.order[k+1:])
+			ctx.order[len(ctx.order)-1] = win
+			ctx.Invalidate()
+			break
+		}
+	}
+}
+
+// Begin adds the window title, when *open is true, with body adding its widgets.  The
+// window is drawn by Render, or straight away in a frame started by SetContext.
+// Closing the window sets *open to false.
+func (ctx *Context) Begin(title string, open *bool, body func(im *Im)) {
+	if !ctx.inFrame {
+		panic("imgio: Begin called outside NewFrame and EndFrame")
+	}
+	if open == nil || !*open {
+		return
+	}
+	win, ok := ctx.windows[title]
+	if !ok {
+		win = &Window{parent: ctx.wm,
+			Size:  f32.Pt(500, 400),
+			title: title,
+		}
+		ctx.restoreWindow(title, win)
+		win.im = ctx.NewIm()
+		win.im.window = win
+		ctx.windows[title] = win
+		ctx.insertWindow(win)
+	}
+	if win.closed {
+		// the close button was pressed when the window was last drawn
+		win.closed = false
+		*open = false
+		return
+	}
+	win.im.Reset(ctx.gtx)
+	body(win.im)
+	if ctx.immediate {
+		ctx.flushDrawLists(ctx.gtx)
+		win.Layout(ctx.gtx, win.im.Layout)
+		return
+	}
+	win.begun = true
+}
+
+// BackgroundDrawList returns the list drawn behind all windows, in screen coordinates.
+// It is drawn and emptied by Render.  In a frame started by SetContext it is drawn as
+// each window is begun, beneath that window.
+func (ctx *Context) BackgroundDrawList() *DrawList {
+	return &ctx.background
+}
+
+// ForegroundDrawList returns the list drawn over all windows, in screen coordinates.
+// It is drawn and emptied by Render, or as each window is begun in a frame started by
+// SetContext.
+func (ctx *Context) ForegroundDrawList() *DrawList {
+	return &ctx.foreground
+}
+
+// loadState reads the saved windows, migrating them from older formats
+func (ctx *Context) loadState() error {
+	data, err := ctx.storage.Load(saveFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err == nil {
+		ctx.state, err = decodeState(data)
+		ctx.newerState = errors.Is(err, ErrNewerState)
+	}
+	if err != nil {
+		return fmt.Errorf("imgio: loading %s: %w", saveFileName, err)
+	}
+	ctx.saved[saveFileName] = data
+	return nil
+}
+
+// Save writes the window state and theme to the context's Storage
+func (ctx *Context) Save() error {
+	return ctx.save(true)
+}
+
+// save marshals the window state and theme, writing those that differ from the last
+// save unless force is set
+func (ctx *Context) save(force bool) error {
+	ctx.lastSave = time.Now()
+	ctx.unsaved = false
+	ctx.storeWindows()
+	ctx.storeTweaks()
+	var errs []error
+	for _, v := range []struct {
+		key   string
+		value any
+	}{
+		{saveFileName, ctx.state},
+		{themeFileName, ctx.imTheme},
+	} {
+		if v.key == saveFileName && ctx.newerState {
+			if force {
+				errs = append(errs, fmt.Errorf("imgio: saving %s: %w", v.key, ErrNewerState))
+			}
+			continue
+		}
+		data, err := json.MarshalIndent(v.value, "", " ")
+		if err == nil && (force || !bytes.Equal(data, ctx.saved[v.key])) {
+			err = ctx.storage.Save(v.key, data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("imgio: saving %s: %w", v.key, err))
+			continue
+		}
+		ctx.saved[v.key] = data
+	}
+	return errors.Join(errs...)
+}
+
+// SetAutosave saves the context every interval while frames are being drawn, and once
+// more an interval after the last frame, so a crash loses at most interval's worth of
+// changes.  Only changed state is written, and errors are passed to onError when it
+// isn't nil.  Autosave is off until this is called, and an interval of zero turns it
+// off again.
+func (ctx *Context) SetAutosave(interval time.Duration, onError func(err error)) {
+	ctx.autosave = interval
+	ctx.onSaveError = onError
+}
+
+// maybeAutosave saves the context when the autosave interval has passed
+func (ctx *Context) maybeAutosave() {
+	if ctx.autosave <= 0 || time.Since(ctx.lastSave) < ctx.autosave {
+		return
+	}
+	if err := ctx.save(false); err != nil && ctx.onSaveError != nil {
+		ctx.onSaveError(err)
+	}
+}
+
+// scheduleAutosave asks for a frame when the autosave interval is up, so changes made
+// just before the app goes idle are still saved.  Nothing is scheduled once a save has
+// caught up with the frames drawn.
+func (ctx *Context) scheduleAutosave() {
+	if ctx.autosave <= 0 || !ctx.unsaved || ctx.app == nil {
+		return
+	}
+	d := ctx.autosave - time.Since(ctx.lastSave)
+	if ctx.saveTimer == nil {
+		ctx.saveTimer = time.AfterFunc(d, ctx.app.Invalidate)
+		return
+	}
+	ctx.saveTimer.Reset(d)
+}
+
+// Close stops autosaving and saves the context, call it when the app's window is
+// destroyed
+func (ctx *Context) Close() error {
+	ctx.autosave = 0
+	if ctx.saveTimer != nil {
+		ctx.saveTimer.Stop()
+	}
+	return ctx.Save()
+}
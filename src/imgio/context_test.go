@@ -0,0 +1,118 @@
+package imgio
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io/fs"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+func testContext(ops *op.Ops) layout.Context {
+	ops.Reset()
+	return layout.Context{
+		Ops:         ops,
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(800, 600)),
+	}
+}
+
+// setDefault makes ctx the default context until the test ends
+func setDefault(t *testing.T, ctx *Context) {
+	prev := gDefault
+	gDefault = ctx
+	requeueTweaks()
+	t.Cleanup(func() {
+		gDefault = prev
+		requeueTweaks()
+	})
+}
+
+// TestLegacyFrames drives the default context the way callers written before
+// NewFrame/Render did: SetContext every frame, windows drawn by Begin, and no Layout.
+func TestLegacyFrames(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	setDefault(t, ctx)
+	var ops op.Ops
+	open := true
+	for frame := 0; frame < 2; frame++ {
+		gtx := testContext(&ops)
+		SetContext(gtx)
+		BackgroundDrawList().AddRectFilled(f32.Pt(0, 0), f32.Pt(10, 10), color.NRGBA{A: 0xff}, 0)
+		ForegroundDrawList().AddCircleFilled(f32.Pt(5, 5), 5, color.NRGBA{A: 0xff})
+		drawn := false
+		Begin("legacy", &open, func(im *Im) {
+			im.Text("frame %d", frame)
+			im.AddWidget(func(gtx layout.Context) layout.Dimensions {
+				drawn = true
+				return layout.Dimensions{}
+			})
+		})
+		if !drawn {
+			t.Fatalf("frame %d: Begin didn't draw the window", frame)
+		}
+		if n := len(ctx.background.cmds) + len(ctx.foreground.cmds); n != 0 {
+			t.Fatalf("frame %d: %d draw list shapes weren't flushed by the window", frame, n)
+		}
+	}
+	// Layout is optional, but still ends the frame
+	Layout()
+	if ctx.inFrame {
+		t.Fatal("Layout left the frame open")
+	}
+}
+
+// TestLegacyFrameAfterNewFrame checks that SetContext drops a frame that NewFrame
+// started but nothing rendered.
+func TestLegacyFrameAfterNewFrame(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops op.Ops
+	open := true
+	ctx.NewFrame(testContext(&ops))
+	ctx.Begin("dropped", &open, func(im *Im) {})
+	ctx.immediateFrame(testContext(&ops))
+	if len(ctx.visible) != 0 {
+		t.Fatalf("dropped frame left %d visible windows", len(ctx.visible))
+	}
+	if !ctx.immediate {
+		t.Fatal("SetContext didn't start an immediate frame")
+	}
+}
+
+// TestAutosaveOptIn checks that nothing is saved until SetAutosave is called
+func TestAutosaveOptIn(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx, err := NewContextWithStorage(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops op.Ops
+	open := true
+	frame := func() {
+		ctx.lastSave = time.Now().Add(-time.Hour)
+		ctx.NewFrame(testContext(&ops))
+		ctx.Begin("window", &open, func(im *Im) {})
+		ctx.Render(testContext(&ops))
+	}
+	frame()
+	if _, err := s.Load(saveFileName); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("saved without autosave: %v", err)
+	}
+	ctx.SetAutosave(time.Minute, nil)
+	frame()
+	if _, err := s.Load(saveFileName); err != nil {
+		t.Fatalf("autosave didn't save: %v", err)
+	}
+}
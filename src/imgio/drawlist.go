@@ -0,0 +1,231 @@
+package imgio
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// DrawList records shapes to be drawn when its owner is laid out.  All coordinates
+// and sizes are in pixels, relative to the owner's top left corner.
+type DrawList struct {
+	cmds []func(gtx layout.Context)
+	// theme styles text, fallbackTheme when nil
+	theme *material.Theme
+}
+
+// fallbackTheme styles the text of draw lists that don't belong to a context
+var fallbackTheme = sync.OnceValue(newTheme)
+
+// Reset drops all recorded shapes
+func (d *DrawList) Reset() {
+	d.cmds = d.cmds[:0]
+}
+
+// Layout draws the recorded shapes
+func (d *DrawList) Layout(gtx layout.Context) {
+	for _, c := range d.cmds {
+		c(gtx)
+	}
+}
+
+func (d *DrawList) add(c func(gtx layout.Context)) {
+	d.cmds = append(d.cmds, c)
+}
+
+func (d *DrawList) stroke(path func(p *clip.Path), col color.NRGBA, thickness float32) {
+	d.add(func(gtx layout.Context) {
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		path(&p)
+		paint.FillShape(gtx.Ops, col, clip.Stroke{Path: p.End(), Width: thickness}.Op())
+	})
+}
+
+func (d *DrawList) fill(path func(p *clip.Path), col color.NRGBA) {
+	d.add(func(gtx layout.Context) {
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		path(&p)
+		paint.FillShape(gtx.Ops, col, clip.Outline{Path: p.End()}.Op())
+	})
+}
+
+// AddLine draws a line from p1 to p2
+func (d *DrawList) AddLine(p1, p2 f32.Point, col color.NRGBA, thickness float32) {
+	d.stroke(func(p *clip.Path) {
+		p.MoveTo(p1)
+		p.LineTo(p2)
+	}, col, thickness)
+}
+
+func rrect(min, max f32.Point, rounding float32) clip.RRect {
+	r := int(rounding)
+	return clip.UniformRRect(image.Rectangle{Min: min.Round(), Max: max.Round()}, r)
+}
+
+// AddRect outlines the rectangle from min to max, with corners rounded by rounding
+func (d *DrawList) AddRect(min, max f32.Point, col color.NRGBA, rounding, thickness float32) {
+	d.add(func(gtx layout.Context) {
+		paint.FillShape(gtx.Ops, col, clip.Stroke{
+			Path:  rrect(min, max, rounding).Path(gtx.Ops),
+			Width: thickness,
+		}.Op())
+	})
+}
+
+// AddRectFilled fills the rectangle from min to max, with corners rounded by rounding
+func (d *DrawList) AddRectFilled(min, max f32.Point, col color.NRGBA, rounding float32) {
+	d.add(func(gtx layout.Context) {
+		paint.FillShape(gtx.Ops, col, rrect(min, max, rounding).Op(gtx.Ops))
+	})
+}
+
+func ellipse(center f32.Point, radius float32) clip.Ellipse {
+	r := f32.Pt(radius, radius)
+	return clip.Ellipse{Min: center.Sub(r).Round(), Max: center.Add(r).Round()}
+}
+
+// AddCircle outlines the circle of radius around center
+func (d *DrawList) AddCircle(center f32.Point, radius float32, col color.NRGBA, thickness float32) {
+	d.add(func(gtx layout.Context) {
+		paint.FillShape(gtx.Ops, col, clip.Stroke{
+			Path:  ellipse(center, radius).Path(gtx.Ops),
+			Width: thickness,
+		}.Op())
+	})
+}
+
+// AddCircleFilled fills the circle of radius around center
+func (d *DrawList) AddCircleFilled(center f32.Point, radius float32, col color.NRGBA) {
+	d.add(func(gtx layout.Context) {
+		paint.FillShape(gtx.Ops, col, ellipse(center, radius).Op(gtx.Ops))
+	})
+}
+
+// AddTriangle outlines the triangle through p1, p2 and p3
+func (d *DrawList) AddTriangle(p1, p2, p3 f32.Point, col color.NRGBA, thickness float32) {
+	d.AddPolyline([]f32.Point{p1, p2, p3}, col, true, thickness)
+}
+
+// AddTriangleFilled fills the triangle through p1, p2 and p3
+func (d *DrawList) AddTriangleFilled(p1, p2, p3 f32.Point, col color.NRGBA) {
+	d.fill(func(p *clip.Path) {
+		p.MoveTo(p1)
+		p.LineTo(p2)
+		p.LineTo(p3)
+		p.Close()
+	}, col)
+}
+
+// AddPolyline draws lines through points, back to the first point when closed
+func (d *DrawList) AddPolyline(points []f32.Point, col color.NRGBA, closed bool, thickness float32) {
+	if len(points) < 2 {
+		return
+	}
+	points = append([]f32.Point(nil), points...)
+	d.stroke(func(p *clip.Path) {
+		p.MoveTo(points[0])
+		for _, pt := range points[1:] {
+			p.LineTo(pt)
+		}
+		if closed {
+			p.Close()
+		}
+	}, col, thickness)
+}
+
+// AddConvexPolyFilled fills the polygon through points
+func (d *DrawList) AddConvexPolyFilled(points []f32.Point, col color.NRGBA) {
+	if len(points) < 3 {
+		return
+	}
+	points = append([]f32.Point(nil), points...)
+	d.fill(func(p *clip.Path) {
+		p.MoveTo(points[0])
+		for _, pt := range points[1:] {
+			p.LineTo(pt)
+		}
+		p.Close()
+	}, col)
+}
+
+// AddBezierCubic draws a cubic bezier from p1 to p2 with control points c1 and c2
+func (d *DrawList) AddBezierCubic(p1, c1, c2, p2 f32.Point, col color.NRGBA, thickness float32) {
+	d.stroke(func(p *clip.Path) {
+		p.MoveTo(p1)
+		p.CubeTo(c1, c2, p2)
+	}, col, thickness)
+}
+
+// AddText draws text with its top left corner at pos, in the theme's text size
+func (d *DrawList) AddText(pos f32.Point, col color.NRGBA, text string) {
+	d.add(func(gtx layout.Context) {
+		defer op.Offset(pos.Round()).Push(gtx.Ops).Pop()
+		gtx.Constraints.Min = image.Point{}
+		th := d.theme
+		if th == nil {
+			th = fallbackTheme()
+		}
+		l := material.Label(th, th.TextSize, text)
+		l.Color = col
+		l.Layout(gtx)
+	})
+}
+
+// AddImage draws img scaled to fill the rectangle from min to max
+func (d *DrawList) AddImage(img paint.ImageOp, min, max f32.Point) {
+	d.add(func(gtx layout.Context) {
+		sz := img.Size()
+		if sz.X == 0 || sz.Y == 0 {
+			return
+		}
+		r := image.Rectangle{Min: min.Round(), Max: max.Round()}
+		defer clip.Rect(r).Push(gtx.Ops).Pop()
+		scale := f32.Pt(float32(r.Dx())/float32(sz.X), float32(r.Dy())/float32(sz.Y))
+		defer op.Affine(f32.Affine2D{}.Scale(f32.Point{}, scale).Offset(layout.FPt(r.Min))).Push(gtx.Ops).Pop()
+		img.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+	})
+}
+
+// DrawList returns the list drawn over the window's contents, in window coordinates.
+// It is emptied each frame.
+func (i *Im) DrawList() *DrawList {
+	return &i.drawList
+}
+
+// ItemDrawList adds an item of size dp and returns a list drawn inside it, in the
+// item's coordinates.  A zero width fills the line.
+func (i *Im) ItemDrawList(size f32.Point) *DrawList {
+	d := &DrawList{theme: i.theme}
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		sz := image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y)))
+		if sz.X <= 0 {
+			sz.X = gtx.Constraints.Max.X
+		}
+		sz = gtx.Constraints.Constrain(sz)
+		defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+		d.Layout(gtx)
+		return layout.Dimensions{Size: sz}
+	})
+	return d
+}
+
+// BackgroundDrawList returns the default context's background draw list
+func BackgroundDrawList() *DrawList {
+	return gDefault.BackgroundDrawList()
+}
+
+// ForegroundDrawList returns the default context's foreground draw list
+func ForegroundDrawList() *DrawList {
+	return gDefault.ForegroundDrawList()
+}
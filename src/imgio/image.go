@@ -0,0 +1,164 @@
+package imgio
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// imageIdentity tells images apart without comparing them, which panics for images
+// of non-comparable types.  Pointer images are identified by address, other images by
+// value.
+type imageIdentity struct {
+	typ   reflect.Type
+	ptr   uintptr
+	value any
+}
+
+// identify returns the identity of img, or false when a non-pointer image isn't
+// comparable and so can't be told apart from others
+func identify(img image.Image) (imageIdentity, bool) {
+	rv := reflect.ValueOf(img)
+	switch {
+	case rv.Kind() == reflect.Pointer:
+		return imageIdentity{typ: rv.Type(), ptr: rv.Pointer()}, true
+	case rv.Comparable():
+		return imageIdentity{value: img}, true
+	}
+	return imageIdentity{}, false
+}
+
+type imageKey struct {
+	img    imageIdentity
+	tint   color.NRGBA
+	filter paint.ImageFilter
+}
+
+type cachedImage struct {
+	op paint.ImageOp
+	// img keeps the image alive while it's cached, so its address isn't reused
+	img  image.Image
+	used bool
+}
+
+// imageCache holds an ImageOp per image so they are only uploaded once.  Images that
+// go a frame without being drawn are dropped, and images that can't be identified are
+// uploaded each time they are drawn.
+type imageCache struct {
+	ops map[imageKey]*cachedImage
+}
+
+func (i *Im) imageOp(img image.Image, tint color.NRGBA) paint.ImageOp {
+	cache := fromCache(i, "##imagecache", func() *imageCache {
+		c := &imageCache{ops: map[imageKey]*cachedImage{}}
+		i.AddUpdater(func() {
+			for k, v := range c.ops {
+				if !v.used {
+					delete(c.ops, k)
+				}
+				v.used = false
+			}
+		})
+		return c
+	})
+	if tint == (color.NRGBA{}) {
+		tint = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	id, cacheable := identify(img)
+	key := imageKey{img: id, tint: tint, filter: i.imageFilter}
+	c, ok := cache.ops[key]
+	if !ok {
+		src := img
+		if tint != (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+			src = tintImage(img, tint)
+		}
+		c = &cachedImage{op: paint.NewImageOp(src), img: img}
+		c.op.Filter = i.imageFilter
+		if cacheable {
+			cache.ops[key] = c
+		}
+	}
+	c.used = true
+	return c.op
+}
+
+// tintImage returns a copy of img with every channel multiplied by tint
+func tintImage(img image.Image, tint color.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	for k := 0; k < len(out.Pix); k += 4 {
+		out.Pix[k] = uint8(uint16(out.Pix[k]) * uint16(tint.R) / 0xff)
+		out.Pix[k+1] = uint8(uint16(out.Pix[k+1]) * uint16(tint.G) / 0xff)
+		out.Pix[k+2] = uint8(uint16(out.Pix[k+2]) * uint16(tint.B) / 0xff)
+		out.Pix[k+3] = uint8(uint16(out.Pix[k+3]) * uint16(tint.A) / 0xff)
+	}
+	return out
+}
+
+// WithImageFilter sets the filter used to scale the images added by body
+func (i *Im) WithImageFilter(filter paint.ImageFilter, body func(im *Im)) {
+	current := i.imageFilter
+	i.imageFilter = filter
+	body(i)
+	i.imageFilter = current
+}
+
+// Image draws the part of img from uv0 to uv1, where 0,0 is the top left and 1,1 the
+// bottom right, scaled to size dp.  A zero size uses the image's size in dp, and a zero
+// tint draws the image unchanged.  The image is uploaded once and must not be modified
+// while it is being drawn, draw a new image instead.
+func (i *Im) Image(img image.Image, size, uv0, uv1 f32.Point, tint color.NRGBA) {
+	imgOp := i.imageOp(img, tint)
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		return layoutImage(gtx, imgOp, size, uv0, uv1)
+	})
+}
+
+// ImageButton is a button showing img at size dp, returning true when clicked
+func (i *Im) ImageButton(id string, img image.Image, size f32.Point) bool {
+	_, id = getId(id, "imagebutton")
+	btn := fromCache(i, id, func() *widget.Clickable {
+		return new(widget.Clickable)
+	})
+	imgOp := i.imageOp(img, color.NRGBA{})
+	b := material.ButtonLayout(i.theme, btn)
+	inset := i.ctx.imTheme.ButtonInset
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		return b.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layoutImage(gtx, imgOp, size, f32.Point{}, f32.Pt(1, 1))
+			})
+		})
+	})
+	return btn.Clicked(i.gtx)
+}
+
+func layoutImage(gtx layout.Context, imgOp paint.ImageOp, size, uv0, uv1 f32.Point) layout.Dimensions {
+	isz := layout.FPt(imgOp.Size())
+	if size == (f32.Point{}) {
+		size = isz.Div(gtx.Metric.PxPerDp)
+	}
+	sz := gtx.Constraints.Constrain(image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y))))
+	uvSize := uv1.Sub(uv0)
+	if isz.X == 0 || isz.Y == 0 || uvSize.X == 0 || uvSize.Y == 0 {
+		return layout.Dimensions{Size: sz}
+	}
+	defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+	scale := f32.Pt(float32(sz.X)/(uvSize.X*isz.X), float32(sz.Y)/(uvSize.Y*isz.Y))
+	origin := f32.Pt(-uv0.X*isz.X*scale.X, -uv0.Y*isz.Y*scale.Y)
+	defer op.Affine(f32.Affine2D{}.Scale(f32.Point{}, scale).Offset(origin)).Push(gtx.Ops).Pop()
+	imgOp.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	return layout.Dimensions{Size: sz}
+}
@@ -0,0 +1,76 @@
+package imgio
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestTintImage(t *testing.T) {
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	for _, tc := range []struct {
+		name string
+		src  color.Color
+		tint color.NRGBA
+		want color.NRGBA
+	}{
+		{"white tint", color.NRGBA{R: 10, G: 20, B: 30, A: 40}, white, color.NRGBA{R: 10, G: 20, B: 30, A: 40}},
+		{"zero tint", color.NRGBA{R: 10, G: 20, B: 30, A: 40}, color.NRGBA{}, color.NRGBA{}},
+		{"red only", white, color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{R: 0xff, A: 0xff}},
+		{"half", white, color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x80}, color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x80}},
+		{"rounds down", color.NRGBA{R: 0xff, G: 1, B: 0x80, A: 0xff}, color.NRGBA{R: 1, G: 0xfe, B: 0x80, A: 0xff}, color.NRGBA{R: 1, G: 0, B: 0x40, A: 0xff}},
+		{"premultiplied source", color.RGBA{R: 0x40, A: 0x80}, white, color.NRGBA{R: 0x7f, A: 0x80}},
+		{"grey source", color.Gray{Y: 0x60}, color.NRGBA{R: 0xff, G: 0x80, A: 0xff}, color.NRGBA{R: 0x60, G: 0x30, A: 0xff}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// an offset image, to check the copy starts at its bounds
+			r := image.Rect(5, 7, 8, 9)
+			var src draw.Image = image.NewNRGBA(r)
+			if _, ok := tc.src.(color.RGBA); ok {
+				// keep a premultiplied source premultiplied
+				src = image.NewRGBA(r)
+			}
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					src.Set(x, y, tc.src)
+				}
+			}
+			out := tintImage(src, tc.tint)
+			if got := out.Bounds(); got != image.Rect(0, 0, 3, 2) {
+				t.Fatalf("bounds %v, want the source size at the origin", got)
+			}
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 3; x++ {
+					if got := out.NRGBAAt(x, y); got != tc.want {
+						t.Fatalf("pixel %d,%d is %v, want %v", x, y, got, tc.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// listImage isn't comparable, so it can't be a map key
+type listImage struct {
+	*image.NRGBA
+	notes []string
+}
+
+func TestImageCacheIdentity(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := ctx.NewIm()
+	a := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	b := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for _, img := range []image.Image{a, a, b, listImage{NRGBA: a}, listImage{NRGBA: a}} {
+		im.imageOp(img, color.NRGBA{})
+	}
+	// a and b are equal but separate images, the list images aren't cached
+	cache := im.widgets["##imagecache"].(*imageCache)
+	if len(cache.ops) != 2 {
+		t.Fatalf("%d images cached, want 2", len(cache.ops))
+	}
+}
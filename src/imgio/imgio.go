@@ -4,10 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"image/color"
-	"os"
 
-	"gioui.org/f32"
 	"gioui.org/layout"
+	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
@@ -33,6 +32,14 @@ type Im struct {
 	gtx             layout.Context
 	FlexWeight      float32
 	minConstraint   *layout.Constraints
+	persisting      bool
+	// indent is added to the left of each line, see TreeNode
+	indent      unit.Dp
+	lineIndent  unit.Dp
+	drawList    DrawList
+	window      *Window
+	imageFilter paint.ImageFilter
+	ctx         *Context
 }
 
 type FlexMode uint8
@@ -43,12 +50,24 @@ const (
 	FlexModeRigid
 )
 
+// NewIm makes an Im drawing with theme in the default context, or in a context of its
+// own that saves nothing when Init hasn't been called.  Use Context.NewIm for an Im in
+// a particular context.
 func NewIm(theme *material.Theme) *Im {
+	ctx := gDefault
+	if ctx == nil {
+		ctx = newContext(nil, NewMemoryStorage(), theme)
+	}
+	return newIm(ctx, theme)
+}
+
+func newIm(ctx *Context, theme *material.Theme) *Im {
 	im := &Im{
 		widgets:    map[string]any{},
 		theme:      theme,
 		axis:       layout.Vertical,
 		FlexWeight: 1,
+		ctx:        ctx,
 	}
 	return im
 }
@@ -70,7 +89,7 @@ func (i *Im) SameLine() {
 		i.AddWidget(i.lastAddedWidget)
 		// remove that last widget from the vertical list
 		wo := i.widgetsOrder
-		i.widgetsOrder = wo[1 : len(wo)-1]
+		i.widgetsOrder = wo[:len(wo)-1]
 	}
 	i.singleSameLine = true
 }
@@ -89,9 +108,21 @@ func (i *Im) WithMinConstraints(c layout.Constraints, body func(im *Im)) {
 	i.minConstraint = current
 }
 
+// WithPersist saves the values edited by the sliders, drags, text inputs, color edits
+// and checkboxes that body adds with the window, restoring them when it's next created.
+// Values are saved under their widget id, so widgets must have distinct ids.
+func (i *Im) WithPersist(body func(im *Im)) {
+	current := i.persisting
+	i.persisting = true
+	body(i)
+	i.persisting = current
+}
+
 func (i *Im) Reset(gtx layout.Context) {
 	i.widgetsOrder = i.widgetsOrder[:0]
 	i.gtx = gtx
+	i.drawList.Reset()
+	i.drawList.theme = i.theme
 
 	for _, u := range i.updaters {
 		u()
@@ -109,9 +140,12 @@ func (i *Im) AddWidget(widget layout.Widget) {
 		}
 	}
 	withInset := func(gtx layout.Context) layout.Dimensions {
-		return gImTheme.WidgetInset.Layout(gtx, widget)
+		return i.ctx.imTheme.WidgetInset.Layout(gtx, widget)
 	}
 	w := withInset
+	if !i.samelineActive && !i.singleSameLine {
+		w = indented(i.indent, w)
+	}
 
 	var flexchild layout.FlexChild
 
@@ -128,6 +162,9 @@ func (i *Im) AddWidget(widget layout.Widget) {
 		}
 	}
 	if i.samelineActive || i.singleSameLine {
+		if i.widgetsHoriz == nil {
+			i.lineIndent = i.indent
+		}
 		i.widgetsHoriz = append(i.widgetsHoriz, flexchild)
 		i.singleSameLine = false
 	} else {
@@ -144,21 +181,69 @@ func (i *Im) EndLine() {
 		w := func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, horiz...)
 		}
-		i.widgetsOrder = append(i.widgetsOrder, layout.Rigid(w))
+		i.widgetsOrder = append(i.widgetsOrder, layout.Rigid(indented(i.lineIndent, w)))
 		i.widgetsHoriz = nil
 	}
 }
 
+// indented wraps a line of widgets so it starts indent from the left
+func indented(indent unit.Dp, line layout.Widget) layout.Widget {
+	if indent == 0 {
+		return line
+	}
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Left: indent}.Layout(gtx, line)
+	}
+}
+
+// persist loads v from the window's saved state the first time id is seen, and saves v
+// with the window from then on.  v must be a pointer.
+func (i *Im) persist(id string, v any) {
+	w := i.window
+	if w == nil {
+		return
+	}
+	if _, ok := w.persisted[id]; !ok {
+		i.restore(id, v)
+	}
+	i.keepPersisted(id, v)
+}
+
+// restore loads v from the window's saved state, when it holds a value for id
+func (i *Im) restore(id string, v any) {
+	if w := i.window; w != nil {
+		if raw, ok := w.state[id]; ok {
+			json.Unmarshal(raw, v)
+		}
+	}
+}
+
+// keepPersisted saves v with the window as the value of id, without loading it first
+func (i *Im) keepPersisted(id string, v any) {
+	w := i.window
+	if w == nil {
+		return
+	}
+	if w.persisted == nil {
+		w.persisted = map[string]any{}
+	}
+	// rebind each time, in case the caller's variable moved
+	w.persisted[id] = v
+}
+
+// persistWidget persists the value v edited by the widget id inside WithPersist
+func (i *Im) persistWidget(id string, v any) {
+	if i.persisting {
+		i.persist(id, v)
+	}
+}
+
 func (i *Im) AddUpdater(updater func()) {
 	i.updaters = append(i.updaters, updater)
 }
 
 func (i *Im) Layout(gtx layout.Context) layout.Dimensions {
 	i.EndLine()
-	spacing := layout.SpaceEnd
-	if i.axis == layout.Horizontal {
-		return layout.Flex{Spacing: layout.SpaceEvenly}.Layout(gtx, i.widgetsOrder...)
-	}
 	return layout.Inset{
 		Left:  unit.Dp(5),
 		Right: unit.Dp(5),
@@ -166,7 +251,7 @@ func (i *Im) Layout(gtx layout.Context) layout.Dimensions {
 		func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{
 				Axis:    i.axis,
-				Spacing: spacing,
+				Spacing: layout.SpaceEnd,
 			}.Layout(
 				gtx,
 				i.widgetsOrder...)
@@ -179,22 +264,37 @@ func (i *Im) Button(label string) bool {
 		return new(widget.Clickable)
 	})
 	b := material.Button(i.theme, btn, label)
-	b.Inset = gImTheme.ButtonInset
+	b.Inset = i.ctx.imTheme.ButtonInset
 	i.AddWidget(b.Layout)
 	return btn.Clicked(i.gtx)
 }
 
+// Checkbox toggles *v when clicked, returning true when it changed
+func (i *Im) Checkbox(label string, v *bool) bool {
+	label, id := getId(label, "checkbox")
+	i.persistWidget(id, v)
+	b := fromCache(i, id, func() *widget.Bool {
+		return new(widget.Bool)
+	})
+	b.Value = *v
+	changed := b.Update(i.gtx)
+	*v = b.Value
+	i.AddWidget(material.CheckBox(i.theme, b, label).Layout)
+	return changed
+}
+
 func (i *Im) Text(s string, args ...any) {
 	i.AddWidget(i.text(s, args...))
 }
 
 func (i *Im) text(s string, args ...any) func(gtx layout.Context) layout.Dimensions {
 	s = fmt.Sprintf(s, args...)
-	return material.Label(i.theme, gTheme.TextSize, s).Layout
+	return material.Label(i.theme, i.theme.TextSize, s).Layout
 }
 
 func (i *Im) InputText(label string, textVariable *string) {
 	label, id := getId(label, "inputtext")
+	i.persistWidget(id, textVariable)
 	lineEditor := fromCache(i, id, func() *widget.Editor {
 		lineEditor := &widget.Editor{
 			SingleLine: true,
@@ -220,6 +320,10 @@ func (i *Im) InputText(label string, textVariable *string) {
 		return lineEditor
 	})
 
+	// typing is one action until the editor loses focus
+	focused := i.gtx.Focused(lineEditor)
+	recordEdit(i, label, id, textVariable, focused, focused)
+
 	inset := layout.UniformInset(unit.Dp(6))
 	editBox := layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 		e := material.Editor(i.theme, lineEditor, "")
@@ -245,15 +349,8 @@ func rigid(inset *layout.Inset, w layout.Widget) layout.FlexChild {
 	})
 }
 
-var (
-	gGtx        layout.Context
-	gTempWm     *WindowManager
-	gWindows    = make(map[string]*Window)
-	gSavedState = make(map[string]json.RawMessage)
-	gTheme      *material.Theme
-	gImTheme    Theme
-	gApp        App
-)
+// gDefault is the context behind the package level functions
+var gDefault *Context
 
 const saveFileName = "imgio.json"
 const themeFileName = "theme.json"
@@ -262,66 +359,79 @@ type App interface {
 	Invalidate()
 }
 
+// Init creates the default context, used by the package level functions
 func Init(a App) {
-	gApp = a
-	gTheme = material.NewTheme()
-	gTheme.Face = "monospace"
-	gImTheme.Palette = &gTheme.Palette
-
-	toLoad, err := os.ReadFile(saveFileName)
-	if err == nil {
-		json.Unmarshal(toLoad, &gSavedState)
-	}
+	gDefault = NewContext(a)
+	requeueTweaks()
+}
 
-	toLoad, err = os.ReadFile(themeFileName)
-	if err == nil {
-		json.Unmarshal(toLoad, &gImTheme)
-	}
+// InitWithStorage creates the default context, keeping its state in s.  See
+// NewContextWithStorage.
+func InitWithStorage(a App, s Storage) error {
+	var err error
+	gDefault, err = NewContextWithStorage(a, s)
+	requeueTweaks()
+	return err
+}
+
+// Default returns the context created by Init
+func Default() *Context {
+	return gDefault
 }
 
 func GetTheme() *material.Theme {
-	return gTheme
+	return gDefault.theme
 }
 
+// NewFrame starts a frame of the default context, see Context.NewFrame
+func NewFrame(gtx layout.Context) {
+	gDefault.NewFrame(gtx)
+}
+
+// EndFrame ends the default context's frame, see Context.EndFrame
+func EndFrame() {
+	gDefault.EndFrame()
+}
+
+// Render draws the default context's frame, see Context.Render
+func Render(gtx layout.Context) {
+	gDefault.Render(gtx)
+}
+
+// SetContext starts a frame of the default context in which Begin draws each window
+// straight away, ending the previous frame if Layout wasn't called.
+//
+// Deprecated: use NewFrame and Render, which draw the windows in z-order.
 func SetContext(gtx layout.Context) {
-	gGtx = gtx
+	gDefault.immediateFrame(gtx)
 }
 
+// Layout ends the frame started by SetContext, drawing what was added to the draw lists
+// after the last window.
+//
+// Deprecated: use Render.
 func Layout() {
+	if !gDefault.immediate {
+		gDefault.Render(gDefault.gtx)
+		return
+	}
+	gDefault.flushDrawLists(gDefault.gtx)
+	gDefault.endImmediateFrame()
 }
 
+// TempSetWm replaces the default context's window manager.
+//
+// Deprecated: the context owns its window manager, NewFrame lays it out.
 func TempSetWm(wm *WindowManager) {
-	gTempWm = wm
+	gDefault.wm = wm
 }
 
 func Begin(title string, open *bool, body func(im *Im)) {
-	if open != nil && *open {
-		win, ok := gWindows[title]
-		if !ok {
-			win = &Window{parent: gTempWm,
-				Size:  f32.Pt(500, 400),
-				title: title,
-			}
-			if val, ok := gSavedState[title]; ok {
-				json.Unmarshal(val, &win)
-			}
-			win.im = NewIm(gTheme)
-			gWindows[title] = win
-		}
-		win.closed = false
-		win.im.Reset(gGtx)
-		body(win.im)
-		win.Layout(gGtx, win.im.Layout)
-		if win.closed {
-			*open = false
-		}
-	}
+	gDefault.Begin(title, open, body)
 }
 
-func DestroyEvent() {
-	toSave, _ := json.MarshalIndent(gWindows, "", " ")
-	os.WriteFile(saveFileName, toSave, os.ModePerm)
-
-	toSave, _ = json.MarshalIndent(gImTheme, "", " ")
-	os.WriteFile(themeFileName, toSave, os.ModePerm)
+// DestroyEvent closes the default context, saving it.  Call it when the app's window is
+// destroyed.
+func DestroyEvent() error {
+	return gDefault.Close()
 }
@@ -0,0 +1,71 @@
+package imgio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+// TestSameLine checks that SameLine moves only the last widget onto the new line
+func TestSameLine(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := ctx.NewIm()
+	var drawn []string
+	widget := func(name string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			drawn = append(drawn, name)
+			return layout.Dimensions{}
+		}
+	}
+	im.AddWidget(widget("a"))
+	im.AddWidget(widget("b"))
+	im.SameLine()
+	im.AddWidget(widget("c"))
+	im.AddWidget(widget("d"))
+
+	var ops op.Ops
+	im.Layout(layout.Context{
+		Ops:         &ops,
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(400, 300)),
+	})
+	if got, want := len(im.widgetsOrder), 3; got != want {
+		t.Fatalf("%d lines, want %d: a, then b and c, then d", got, want)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		found := false
+		for _, d := range drawn {
+			found = found || d == name
+		}
+		if !found {
+			t.Fatalf("widget %s wasn't drawn, drew %v", name, drawn)
+		}
+	}
+}
+
+// TestNewImWithoutInit uses an Im and a draw list before any default context exists
+func TestNewImWithoutInit(t *testing.T) {
+	setDefault(t, nil)
+	im := NewIm(newTheme())
+	var ops op.Ops
+	gtx := testContext(&ops)
+	im.Reset(gtx)
+	im.Text("text")
+	im.Button("button")
+	im.DrawList().AddText(f32.Pt(0, 0), color.NRGBA{A: 0xff}, "drawn")
+	im.Layout(gtx)
+	var d DrawList
+	d.AddText(f32.Pt(0, 0), color.NRGBA{A: 0xff}, "no theme")
+	d.Layout(gtx)
+	if gDefault != nil {
+		t.Fatal("NewIm set the default context")
+	}
+}
@@ -0,0 +1,306 @@
+package imgio
+
+import (
+	"cmp"
+	"fmt"
+	"image/color"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// inspectTag is the parsed `imgio:"..."` tag of a struct field
+type inspectTag struct {
+	min, max float64
+	step     float64
+	readonly bool
+	hidden   bool
+}
+
+func parseInspectTag(tag string, readonly bool) inspectTag {
+	t := inspectTag{min: math.Inf(-1), max: math.Inf(1), readonly: readonly}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		f, _ := strconv.ParseFloat(value, 64)
+		switch key {
+		case "min":
+			t.min = f
+		case "max":
+			t.max = f
+		case "step":
+			t.step = f
+		case "readonly":
+			t.readonly = true
+		case "hidden":
+			t.hidden = true
+		}
+	}
+	return t
+}
+
+// inspectShadow is a copy of an inspected value for the widgets that keep a pointer to
+// what they edit.  Edits are copied back on the next frame.
+type inspectShadow[T comparable] struct {
+	value, synced T
+}
+
+// shadowOf returns the shadow for id, after copying an edit to it back with set or
+// picking up the current value
+func shadowOf[T comparable](im *Im, id string, current T, set func(T)) *T {
+	s := fromCache(im, id+"/shadow", func() *inspectShadow[T] {
+		return &inspectShadow[T]{value: current, synced: current}
+	})
+	if s.value != s.synced {
+		set(s.value)
+	} else {
+		s.value = current
+	}
+	s.synced = s.value
+	return &s.value
+}
+
+var nrgbaType = reflect.TypeOf(color.NRGBA{})
+
+// isLeaf reports whether values of t are edited by a single widget, rather than a tree
+func isLeaf(t reflect.Type) bool {
+	if t == nrgbaType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return false
+	}
+	return true
+}
+
+// inspectRow adds the widget for an element followed by its Remove button, on the same
+// line when the element is a single widget
+func (i *Im) inspectRow(leaf bool, element func(im *Im), remove func(im *Im)) {
+	if !leaf {
+		element(i)
+		remove(i)
+		return
+	}
+	i.WithSameLine(func(im *Im) {
+		element(im)
+		im.WithFlexMode(FlexModeRigid, remove)
+	})
+}
+
+// Inspect edits the fields of the struct v points to with the matching widget for each,
+// and nested structs, slices and maps as tree nodes.  Floats get a DragFloat, integers a
+// DragInt, strings an InputText, color.NRGBA a ColorEdit and bools a Checkbox.  Fields
+// tagged `imgio:"min=0,max=1,step=0.01"` are limited and dragged by step per pixel, and
+// the readonly and hidden options show a field as text or not at all.  Unexported
+// fields are skipped.  v may point to any other value, which is edited under label.
+// returns true if any value changed
+func (i *Im) Inspect(label string, v any) bool {
+	label, id := getId(label, "inspect")
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		i.Text("%s: %v", label, v)
+		return false
+	}
+	rv = rv.Elem()
+	tag := parseInspectTag("", false)
+	if rv.Kind() == reflect.Struct && rv.Type() != nrgbaType {
+		return i.inspectFields(id, rv, tag)
+	}
+	return i.inspectValue(label, id, rv, tag)
+}
+
+func (i *Im) inspectFields(id string, rv reflect.Value, parent inspectTag) bool {
+	changed := false
+	for k := range rv.NumField() {
+		f := rv.Type().Field(k)
+		if !f.IsExported() {
+			continue
+		}
+		tag := parseInspectTag(f.Tag.Get("imgio"), parent.readonly)
+		if tag.hidden {
+			continue
+		}
+		changed = i.inspectValue(f.Name, id+"/"+f.Name, rv.Field(k), tag) || changed
+	}
+	return changed
+}
+
+// inspectValue adds the widget editing rv, which is settable unless read through a map
+func (i *Im) inspectValue(name, id string, rv reflect.Value, tag inspectTag) bool {
+	label := name + "##" + id
+	if tag.readonly && isLeaf(rv.Type()) {
+		i.Text("%s: %v", name, rv.Interface())
+		return false
+	}
+	changed := false
+	set := func(f func()) {
+		f()
+		changed = true
+	}
+	switch {
+	case rv.Type() == nrgbaType:
+		c := shadowOf(i, id, rv.Interface().(color.NRGBA), func(c color.NRGBA) {
+			set(func() { rv.Set(reflect.ValueOf(c)) })
+		})
+		i.ColorEdit(label, c)
+	case rv.CanFloat():
+		f := shadowOf(i, id, rv.Float(), func(f float64) {
+			set(func() { rv.SetFloat(f) })
+		})
+		i.DragFloat(label, f, cmp.Or(tag.step, 0.01), tag.min, tag.max, "%.3f")
+	case rv.CanInt(), rv.CanUint():
+		// the drag edits an int64, so the range is limited to what both it and the
+		// field hold and unsigned values above MaxInt64 show as MaxInt64
+		var current int64
+		bits := rv.Type().Bits()
+		minv, maxv := tag.min, tag.max
+		if rv.CanInt() {
+			current = rv.Int()
+			minv = max(minv, -math.Ldexp(1, bits-1))
+			maxv = min(maxv, math.Ldexp(1, bits-1)-1)
+		} else {
+			current = int64(min(rv.Uint(), math.MaxInt64))
+			minv = max(minv, 0)
+			maxv = min(maxv, math.Ldexp(1, bits)-1)
+		}
+		n := shadowOf(i, id, current, func(n int64) {
+			set(func() {
+				if rv.CanInt() {
+					rv.SetInt(n)
+				} else {
+					rv.SetUint(uint64(max(n, 0)))
+				}
+			})
+		})
+		i.WithSameLine(func(im *Im) {
+			im.DragInt(label, n, cmp.Or(tag.step, 0.1), intBound(minv), intBound(maxv), "%d")
+			im.WithFlexMode(FlexModeRigid, func(im *Im) {
+				im.Text(name)
+			})
+		})
+	case rv.Kind() == reflect.String:
+		s := shadowOf(i, id, rv.String(), func(s string) {
+			set(func() { rv.SetString(s) })
+		})
+		i.InputText(label, s)
+	case rv.Kind() == reflect.Bool:
+		b := rv.Bool()
+		if i.Checkbox(label, &b) {
+			set(func() { rv.SetBool(b) })
+		}
+	case rv.Kind() == reflect.Struct:
+		i.TreeNode(label, func(im *Im) {
+			changed = im.inspectFields(id, rv, tag)
+		})
+	case rv.Kind() == reflect.Pointer:
+		if rv.IsNil() {
+			i.Text("%s: nil", name)
+			break
+		}
+		changed = i.inspectValue(name, id, rv.Elem(), tag)
+	case rv.Kind() == reflect.Slice, rv.Kind() == reflect.Array:
+		changed = i.inspectList(name, id, rv, tag)
+	case rv.Kind() == reflect.Map:
+		changed = i.inspectMap(name, id, rv, tag)
+	default:
+		i.Text("%s: %v", name, rv.Interface())
+	}
+	return changed
+}
+
+// inspectList adds a tree node holding the elements of a slice or array.  Slices get
+// buttons to add and remove elements.
+func (i *Im) inspectList(name, id string, rv reflect.Value, tag inspectTag) bool {
+	changed := false
+	editable := rv.Kind() == reflect.Slice && !tag.readonly
+	i.TreeNode(fmt.Sprintf("%s [%d]###%s", name, rv.Len(), id), func(im *Im) {
+		remove := -1
+		for k := range rv.Len() {
+			elemId := fmt.Sprintf("%s/%d", id, k)
+			im.inspectRow(isLeaf(rv.Type().Elem()), func(im *Im) {
+				changed = im.inspectValue(fmt.Sprintf("[%d]", k), elemId, rv.Index(k), tag) || changed
+			}, func(im *Im) {
+				if editable && im.Button("Remove##"+elemId) {
+					remove = k
+				}
+			})
+		}
+		if !editable {
+			return
+		}
+		if remove >= 0 {
+			rv.Set(reflect.AppendSlice(rv.Slice(0, remove), rv.Slice(remove+1, rv.Len())))
+			changed = true
+		}
+		if im.Button("Add##" + id) {
+			rv.Set(reflect.Append(rv, reflect.Zero(rv.Type().Elem())))
+			changed = true
+		}
+	})
+	return changed
+}
+
+// inspectMapState holds copies of a map's values while they are edited, since map
+// values can't be set in place, and the key of the next entry to add
+type inspectMapState struct {
+	values map[string]reflect.Value
+	key    reflect.Value
+}
+
+// inspectMap adds a tree node holding the entries of a map, sorted by key, with buttons
+// to remove them and to add an entry under a new key
+func (i *Im) inspectMap(name, id string, rv reflect.Value, tag inspectTag) bool {
+	changed := false
+	i.TreeNode(fmt.Sprintf("%s [%d]###%s", name, rv.Len(), id), func(im *Im) {
+		s := fromCache(im, id+"/map", func() *inspectMapState {
+			return &inspectMapState{
+				values: map[string]reflect.Value{},
+				key:    reflect.New(rv.Type().Key()).Elem(),
+			}
+		})
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		})
+		for _, key := range keys {
+			keyName := fmt.Sprint(key)
+			elemId := id + "/" + keyName
+			value, ok := s.values[keyName]
+			if !ok {
+				value = reflect.New(rv.Type().Elem()).Elem()
+				s.values[keyName] = value
+			}
+			value.Set(rv.MapIndex(key))
+			im.inspectRow(isLeaf(rv.Type().Elem()), func(im *Im) {
+				if im.inspectValue(keyName, elemId, value, tag) {
+					rv.SetMapIndex(key, value)
+					changed = true
+				}
+			}, func(im *Im) {
+				if !tag.readonly && im.Button("Remove##"+elemId) {
+					rv.SetMapIndex(key, reflect.Value{})
+					delete(s.values, keyName)
+					changed = true
+				}
+			})
+		}
+		if tag.readonly {
+			return
+		}
+		im.WithSameLine(func(im *Im) {
+			im.inspectValue("key", id+"/newkey", s.key, inspectTag{min: math.Inf(-1), max: math.Inf(1)})
+			im.WithFlexMode(FlexModeRigid, func(im *Im) {
+				if im.Button("Add##"+id) && !rv.MapIndex(s.key).IsValid() {
+					if rv.IsNil() {
+						rv.Set(reflect.MakeMap(rv.Type()))
+					}
+					rv.SetMapIndex(s.key, reflect.Zero(rv.Type().Elem()))
+					changed = true
+				}
+			})
+		})
+	})
+	return changed
+}
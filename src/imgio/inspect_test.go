@@ -0,0 +1,203 @@
+package imgio
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"testing"
+
+	"gioui.org/op"
+	"gioui.org/widget"
+)
+
+func TestParseInspectTag(t *testing.T) {
+	inf := math.Inf(1)
+	for _, tc := range []struct {
+		tag      string
+		readonly bool
+		want     inspectTag
+	}{
+		{"", false, inspectTag{min: -inf, max: inf}},
+		{"", true, inspectTag{min: -inf, max: inf, readonly: true}},
+		{"min=0,max=10", false, inspectTag{min: 0, max: 10}},
+		{"min=-1.5, max=2.5, step=0.25", false, inspectTag{min: -1.5, max: 2.5, step: 0.25}},
+		{"readonly", false, inspectTag{min: -inf, max: inf, readonly: true}},
+		{"hidden", false, inspectTag{min: -inf, max: inf, hidden: true}},
+		{"max=1,readonly,hidden", false, inspectTag{min: -inf, max: 1, readonly: true, hidden: true}},
+		{"step=1e-3", false, inspectTag{min: -inf, max: inf, step: 0.001}},
+		{"unknown=3,min=2", false, inspectTag{min: 2, max: inf}},
+		// a value that doesn't parse sets zero
+		{"min=abc", false, inspectTag{min: 0, max: inf}},
+	} {
+		if got := parseInspectTag(tc.tag, tc.readonly); got != tc.want {
+			t.Errorf("parseInspectTag(%q, %v) = %+v, want %+v", tc.tag, tc.readonly, got, tc.want)
+		}
+	}
+}
+
+type inspected struct {
+	F      float64 `imgio:"min=0,max=1"`
+	N      int
+	U      uint8
+	Big    uint64
+	S      string
+	Hidden int `imgio:"hidden"`
+	Fixed  int `imgio:"readonly"`
+	Inner  struct{ X float32 }
+	Items  []int
+	Named  map[string]int
+	secret int
+}
+
+// inspectFrame lays out one frame of im inspecting v, returning what Inspect returned
+func inspectFrame(im *Im, v *inspected) bool {
+	var ops op.Ops
+	gtx := testContext(&ops)
+	im.Reset(gtx)
+	changed := im.Inspect("v", v)
+	im.Layout(gtx)
+	return changed
+}
+
+// setShadow edits the shadow of the widget for id, as dragging or typing would
+func setShadow[T comparable](t *testing.T, im *Im, id string, v T) {
+	t.Helper()
+	s, ok := im.widgets[id+"/shadow"].(*inspectShadow[T])
+	if !ok {
+		t.Fatalf("no %T shadow for %s", v, id)
+	}
+	s.value = v
+}
+
+// click clicks the button for id on the next frame
+func click(im *Im, id string) {
+	fromCache(im, id+"button", func() *widget.Clickable {
+		return new(widget.Clickable)
+	}).Click()
+}
+
+// openNode opens the tree node for id, which must have been laid out
+func openNode(t *testing.T, im *Im, id string) {
+	t.Helper()
+	n, ok := im.widgets[id+"treenode"].(*treeNode)
+	if !ok {
+		t.Fatalf("no tree node %s", id)
+	}
+	n.Open = true
+}
+
+func newInspectIm(t *testing.T) *Im {
+	t.Helper()
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx.NewIm()
+}
+
+func TestInspectStruct(t *testing.T) {
+	im := newInspectIm(t)
+	v := inspected{F: 0.25, N: -3, S: "a", Fixed: 7}
+	if inspectFrame(im, &v) {
+		t.Fatal("changed without an edit")
+	}
+	for _, id := range []string{"vinspect/Hidden", "vinspect/Fixed", "vinspect/secret"} {
+		if _, ok := im.widgets[id+"/shadow"]; ok {
+			t.Errorf("%s has an editor", id)
+		}
+	}
+	setShadow(t, im, "vinspect/F", 0.5)
+	setShadow(t, im, "vinspect/N", int64(4))
+	setShadow(t, im, "vinspect/S", "b")
+	if !inspectFrame(im, &v) {
+		t.Fatal("edits not reported")
+	}
+	if v.F != 0.5 || v.N != 4 || v.S != "b" {
+		t.Fatalf("edited to %+v", v)
+	}
+
+	// the nested struct is edited once its node is open
+	openNode(t, im, "Inner##vinspect/Inner")
+	inspectFrame(im, &v)
+	setShadow(t, im, "vinspect/Inner/X", 2.0)
+	inspectFrame(im, &v)
+	if v.Inner.X != 2 {
+		t.Fatalf("nested X is %v, want 2", v.Inner.X)
+	}
+
+	// a caller change shows in the editor rather than being overwritten
+	v.N = 10
+	if inspectFrame(im, &v) || v.N != 10 {
+		t.Fatalf("caller change lost, N is %d", v.N)
+	}
+}
+
+func TestInspectUint(t *testing.T) {
+	im := newInspectIm(t)
+	v := inspected{U: 3, Big: math.MaxUint64}
+	inspectFrame(im, &v)
+	setShadow(t, im, "vinspect/U", int64(-5))
+	inspectFrame(im, &v)
+	if v.U != 0 {
+		t.Fatalf("dragging below zero set %d, want 0", v.U)
+	}
+	// a value above MaxInt64 shows as MaxInt64 and is left alone until edited
+	inspectFrame(im, &v)
+	if v.Big != math.MaxUint64 {
+		t.Fatalf("unedited value became %d", v.Big)
+	}
+	if s := im.widgets["vinspect/Big/shadow"].(*inspectShadow[int64]); s.value != math.MaxInt64 {
+		t.Fatalf("shown as %d, want MaxInt64", s.value)
+	}
+}
+
+func TestInspectSlice(t *testing.T) {
+	im := newInspectIm(t)
+	var v inspected
+	inspectFrame(im, &v)
+	openNode(t, im, "###vinspect/Items")
+	click(im, "Add##vinspect/Items")
+	if !inspectFrame(im, &v) || !slices.Equal(v.Items, []int{0}) {
+		t.Fatalf("added to %v", v.Items)
+	}
+	click(im, "Add##vinspect/Items")
+	inspectFrame(im, &v)
+	// the new element's widget is there from the next frame
+	inspectFrame(im, &v)
+	setShadow(t, im, "vinspect/Items/1", int64(5))
+	inspectFrame(im, &v)
+	if !slices.Equal(v.Items, []int{0, 5}) {
+		t.Fatalf("edited to %v", v.Items)
+	}
+	click(im, "Remove##vinspect/Items/0")
+	if !inspectFrame(im, &v) || !slices.Equal(v.Items, []int{5}) {
+		t.Fatalf("removed to %v", v.Items)
+	}
+}
+
+func TestInspectMap(t *testing.T) {
+	im := newInspectIm(t)
+	var v inspected
+	inspectFrame(im, &v)
+	openNode(t, im, "###vinspect/Named")
+	inspectFrame(im, &v)
+	setShadow(t, im, "vinspect/Named/newkey", "a")
+	inspectFrame(im, &v)
+	click(im, "Add##vinspect/Named")
+	if !inspectFrame(im, &v) || !maps.Equal(v.Named, map[string]int{"a": 0}) {
+		t.Fatalf("added to %v", v.Named)
+	}
+	// adding an existing key keeps its value
+	inspectFrame(im, &v)
+	setShadow(t, im, "vinspect/Named/a", int64(3))
+	inspectFrame(im, &v)
+	click(im, "Add##vinspect/Named")
+	inspectFrame(im, &v)
+	if !maps.Equal(v.Named, map[string]int{"a": 3}) {
+		t.Fatalf("edited to %v", v.Named)
+	}
+	click(im, "Remove##vinspect/Named/a")
+	if !inspectFrame(im, &v) || len(v.Named) != 0 {
+		t.Fatalf("removed to %v", v.Named)
+	}
+}
@@ -0,0 +1,147 @@
+package imgio
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/paint"
+)
+
+var inspectorChannels = []string{"RGBA", "R", "G", "B", "A"}
+
+type imageInspector struct {
+	// img identifies the image analysed, see identify
+	img imageIdentity
+	// channel is 0 for the whole image, or 1-4 to show only R, G, B or A
+	channel  int
+	isolated [4]*image.Gray
+	// hist holds 256 bins for luminance then each of R, G, B and A
+	hist [5][]float32
+	fit  bool
+}
+
+// analyse builds the histograms and the single channel images for img
+func (s *imageInspector) analyse(img image.Image) {
+	b := img.Bounds()
+	for k := range s.isolated {
+		s.isolated[k] = image.NewGray(image.Rectangle{Max: b.Size()})
+	}
+	for k := range s.hist {
+		s.hist[k] = make([]float32, 256)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			luma := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+			s.hist[0][luma]++
+			for k, v := range [4]uint8{c.R, c.G, c.B, c.A} {
+				s.hist[k+1][v]++
+				s.isolated[k].SetGray(x-b.Min.X, y-b.Min.Y, color.Gray{Y: v})
+			}
+		}
+	}
+}
+
+// ImageInspector shows img on a canvas for close examination.  Left or middle drag pans,
+// the wheel zooms and a pixel grid appears once pixels are large enough.  The pixel
+// under the pointer is read out below, and the buttons show a single channel.
+// The image is analysed once, so draw a new image rather than modifying it.  Images of
+// non-comparable, non-pointer types are analysed every frame.
+func (i *Im) ImageInspector(id string, img image.Image) {
+	_, id = getId(id, "imageinspector")
+	s := fromCache(i, id, func() *imageInspector {
+		return &imageInspector{fit: true}
+	})
+	if id, ok := identify(img); !ok || s.img != id {
+		s.img = id
+		s.analyse(img)
+	}
+	b := img.Bounds()
+
+	i.WithSameLine(func(im *Im) {
+		im.WithFlexMode(FlexModeRigid, func(im *Im) {
+			for k, name := range inspectorChannels {
+				if k == s.channel {
+					name = "[" + name + "]"
+				}
+				if im.Button(name + "###" + id + "/" + inspectorChannels[k]) {
+					s.channel = k
+				}
+			}
+			if im.Button("Fit##" + id) {
+				s.fit = true
+			}
+		})
+	})
+
+	var readout string
+	i.WithImageFilter(paint.FilterNearest, func(im *Im) {
+		im.Canvas("##"+id+"/view", f32.Point{}, func(c *Canvas) {
+			c.GridStep = 0
+			c.MaxZoom = 256
+			c.PanButtons = pointer.ButtonPrimary | pointer.ButtonTertiary
+			size := layout.FPt(b.Size())
+			if s.fit && c.Size().X > 0 {
+				view := c.Size()
+				c.View.Zoom = min(view.X/size.X, view.Y/size.Y)
+				c.CenterOn(size.Mul(0.5))
+				s.fit = false
+			}
+
+			var shown image.Image = img
+			if s.channel > 0 {
+				shown = s.isolated[s.channel-1]
+			}
+			dl := c.DrawList()
+			dl.AddImage(im.imageOp(shown, color.NRGBA{}), c.ToScreen(f32.Point{}), c.ToScreen(size))
+			if c.View.Zoom >= 8 {
+				drawPixelGrid(dl, c, b.Size(), i.theme.Fg)
+			}
+
+			if !c.Hovered() {
+				return
+			}
+			p := c.MousePos()
+			x, y := int(math.Floor(float64(p.X))), int(math.Floor(float64(p.Y)))
+			if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+				return
+			}
+			px := f32.Pt(float32(x), float32(y))
+			dl.AddRect(c.ToScreen(px), c.ToScreen(px.Add(f32.Pt(1, 1))), i.theme.Fg, 0, 1)
+			c8 := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			c16 := color.NRGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA64)
+			readout = fmt.Sprintf("(%d, %d)  %d %d %d %d  %.3f %.3f %.3f %.3f", x, y,
+				c8.R, c8.G, c8.B, c8.A,
+				float64(c16.R)/0xffff, float64(c16.G)/0xffff, float64(c16.B)/0xffff, float64(c16.A)/0xffff)
+		})
+	})
+	if readout == "" {
+		readout = fmt.Sprintf("%d x %d", b.Dx(), b.Dy())
+	}
+	i.Text("%s", readout)
+	overlay := inspectorChannels[s.channel]
+	if s.channel == 0 {
+		overlay = "Luminance"
+	}
+	nan := float32(math.NaN())
+	i.PlotHistogram("##"+id+"/histogram", s.hist[s.channel], 0, overlay, 0, nan, f32.Point{})
+}
+
+// drawPixelGrid outlines the visible pixels of an image of size sz
+func drawPixelGrid(dl *DrawList, c *Canvas, sz image.Point, col color.NRGBA) {
+	col.A = 0x40
+	tl, br := c.ToWorld(f32.Point{}), c.ToWorld(c.Size())
+	x0, x1 := max(int(tl.X), 0), min(int(br.X)+1, sz.X)
+	y0, y1 := max(int(tl.Y), 0), min(int(br.Y)+1, sz.Y)
+	for x := x0; x <= x1; x++ {
+		dl.AddLine(c.ToScreen(f32.Pt(float32(x), float32(y0))), c.ToScreen(f32.Pt(float32(x), float32(y1))), col, 1)
+	}
+	for y := y0; y <= y1; y++ {
+		dl.AddLine(c.ToScreen(f32.Pt(float32(x0), float32(y))), c.ToScreen(f32.Pt(float32(x1), float32(y))), col, 1)
+	}
+}
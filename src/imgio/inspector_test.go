@@ -0,0 +1,37 @@
+package imgio
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/op"
+)
+
+func TestImageInspectorIdentity(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := ctx.NewIm()
+	a := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	var ops op.Ops
+	frame := func(img image.Image) *imageInspector {
+		gtx := testContext(&ops)
+		im.Reset(gtx)
+		im.ImageInspector("inspect", img)
+		im.Layout(gtx)
+		return im.widgets["inspectimageinspector"].(*imageInspector)
+	}
+	analysed := frame(a).isolated[0]
+	if frame(a).isolated[0] != analysed {
+		t.Fatal("the same image was analysed again")
+	}
+	if frame(image.NewNRGBA(a.Rect)).isolated[0] == analysed {
+		t.Fatal("a new image wasn't analysed")
+	}
+	// a non-comparable image can't be told apart from the last, so it's analysed again
+	analysed = frame(listImage{NRGBA: a}).isolated[0]
+	if frame(listImage{NRGBA: a}).isolated[0] == analysed {
+		t.Fatal("a non-comparable image wasn't analysed again")
+	}
+}
@@ -0,0 +1,140 @@
+package imgio
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// WindowLayout is a named arrangement of windows, see Context.SaveLayout
+type WindowLayout struct {
+	// Windows holds each window's position, size and collapse state, by title
+	Windows map[string]json.RawMessage
+	// Order lists window titles from the back to the front
+	Order []string `json:",omitempty"`
+}
+
+// SaveLayout saves the position, size, collapse state and z-order of every window as the
+// layout called name, replacing any layout of that name.  Windows known from the saved
+// state but not open are included.
+func (ctx *Context) SaveLayout(name string) {
+	ctx.storeWindows()
+	p := ctx.state.current()
+	if ctx.state.Layouts == nil {
+		ctx.state.Layouts = map[string]*WindowLayout{}
+	}
+	ctx.state.Layouts[name] = &WindowLayout{
+		Windows: maps.Clone(p.Windows),
+		Order:   slices.Clone(p.Order),
+	}
+}
+
+// LoadLayout moves the windows to the layout called name.  Windows the layout doesn't
+// mention stay where they are.
+func (ctx *Context) LoadLayout(name string) error {
+	l, ok := ctx.state.Layouts[name]
+	if !ok {
+		return fmt.Errorf("imgio: no layout called %q", name)
+	}
+	ctx.storeWindows()
+	p := ctx.state.current()
+	for title, raw := range l.Windows {
+		p.Windows[title] = raw
+		if win, ok := ctx.windows[title]; ok {
+			win.restore(raw)
+		}
+	}
+	p.Order = slices.Clone(l.Order)
+	ctx.applyOrder(l.Order)
+	ctx.Invalidate()
+	return nil
+}
+
+// DeleteLayout removes the layout called name
+func (ctx *Context) DeleteLayout(name string) {
+	delete(ctx.state.Layouts, name)
+}
+
+// ListLayouts returns the names of the saved layouts, sorted
+func (ctx *Context) ListLayouts() []string {
+	return slices.Sorted(maps.Keys(ctx.state.Layouts))
+}
+
+// applyOrder sorts the open windows into order.  Windows it doesn't name go in front,
+// keeping their current order.
+func (ctx *Context) applyOrder(order []string) {
+	rank := func(w *Window) int {
+		if k := slices.Index(order, w.title); k >= 0 {
+			return k
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(ctx.order, func(a, b *Window) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+}
+
+// layoutsEdit is the state of the Layouts window
+type layoutsEdit struct {
+	name string
+	err  error
+}
+
+// Layouts opens a window listing the saved layouts, to load, delete or save the current
+// arrangement under a new name
+func (ctx *Context) Layouts(open *bool) {
+	ctx.Begin("Layouts", open, func(im *Im) {
+		e := fromCache(im, "##layouts", func() *layoutsEdit {
+			return &layoutsEdit{}
+		})
+		for _, name := range ctx.ListLayouts() {
+			im.WithSameLine(func(im *Im) {
+				im.WithFlexMode(FlexModeFlex, func(im *Im) {
+					im.Text("%s", name)
+				})
+				im.WithFlexMode(FlexModeRigid, func(im *Im) {
+					if im.Button("Load##" + name) {
+						e.err = ctx.LoadLayout(name)
+					}
+					if im.Button("Delete##" + name) {
+						ctx.DeleteLayout(name)
+					}
+				})
+			})
+		}
+		im.WithSameLine(func(im *Im) {
+			im.InputText("##layoutname", &e.name)
+			im.WithFlexMode(FlexModeRigid, func(im *Im) {
+				if im.Button("Save") && e.name != "" {
+					ctx.SaveLayout(e.name)
+					e.err = nil
+				}
+			})
+		})
+		if e.err != nil {
+			im.Text("%v", e.err)
+		}
+	})
+}
+
+// SaveLayout saves the default context's layout, see Context.SaveLayout
+func SaveLayout(name string) {
+	gDefault.SaveLayout(name)
+}
+
+// LoadLayout loads one of the default context's layouts, see Context.LoadLayout
+func LoadLayout(name string) error {
+	return gDefault.LoadLayout(name)
+}
+
+// ListLayouts returns the names of the default context's layouts
+func ListLayouts() []string {
+	return gDefault.ListLayouts()
+}
+
+// Layouts opens the default context's Layouts window
+func Layouts(open *bool) {
+	gDefault.Layouts(open)
+}
@@ -0,0 +1,50 @@
+package imgio
+
+import (
+	"testing"
+
+	"gioui.org/op"
+)
+
+func TestLoadLayoutExpandsWindows(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops op.Ops
+	open := true
+	ctx.NewFrame(testContext(&ops))
+	ctx.Begin("w", &open, func(im *Im) {})
+	ctx.Render(testContext(&ops))
+
+	win := ctx.windows["w"]
+	ctx.SaveLayout("expanded")
+	win.Collapsed = true
+	if err := ctx.LoadLayout("expanded"); err != nil {
+		t.Fatal(err)
+	}
+	if win.Collapsed {
+		t.Fatal("loading a layout saved expanded left the window collapsed")
+	}
+}
+
+func TestPanelsStayOutOfLayouts(t *testing.T) {
+	ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops op.Ops
+	open := true
+	ctx.Panel(testContext(&ops), "tools", func(im *Im) {
+		im.WithPersist(func(im *Im) {
+			im.Checkbox("snap", &open)
+		})
+	})
+	ctx.SaveLayout("l")
+	if n := len(ctx.state.Layouts["l"].Windows); n != 0 {
+		t.Fatalf("layout holds %d windows, want none", n)
+	}
+	if _, ok := ctx.state.current().Widgets[panelKey("tools")]; !ok {
+		t.Fatal("the panel's persisted values weren't saved")
+	}
+}
@@ -0,0 +1,496 @@
+// Package nodes is a node graph editor built on imgio.
+//
+// Nodes are declared every frame inside BeginNodeEditor, each with input and output
+// pins and a body of ordinary Im widgets.  Dragging from a pin to a pin of the other
+// kind links them, and dragging away from a linked input detaches its link.  Clicking a
+// link selects it for the delete key, alt clicking deletes it straight away.  Left drag
+// on the background box selects nodes, dragging a node's title moves every selected
+// node, middle drag pans and clicking the minimap jumps to that part of the graph.
+package nodes
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"github.com/bradbev/imgio/src/imgio"
+)
+
+// Pin names a pin on a node
+type Pin struct {
+	Node, Pin string
+}
+
+// Link joins an output pin to an input pin
+type Link struct {
+	From, To Pin
+}
+
+// Editor is a node graph, created by BeginNodeEditor and filled with nodes from its
+// body.  The Editor persists between frames, so options can be set once or every frame.
+type Editor struct {
+	// Links are the graph's links.  The editor adds and removes links as they are
+	// dragged and deleted, and they can be changed freely from the body.
+	Links []Link
+	// Height of the editor, 400dp when zero
+	Height unit.Dp
+	// NoMinimap hides the minimap
+	NoMinimap bool
+	// CanLink, when set, decides whether a link may be made from an output to an input
+	CanLink func(from, to Pin) bool
+
+	im       *imgio.Im
+	saved    savedState
+	nodes    map[string]*Node
+	order    []*Node
+	pins     map[Pin]*pinState
+	selected map[string]bool
+	// link is the selected link, nil when there is none.  It is kept by value so it
+	// survives the body changing Links.
+	link *Link
+	size image.Point
+
+	created, deleted       []Link
+	newCreated, newDeleted []Link
+
+	// interaction state, positions are relative to the editor
+	panning bool
+	boxing  bool
+	moving  bool
+	linking bool
+	last    f32.Point
+	box     [2]f32.Point
+	from    *pinState
+	linkEnd f32.Point
+	mini    minimap
+}
+
+// savedState is persisted with the window
+type savedState struct {
+	Pan       f32.Point
+	Positions map[string]f32.Point
+}
+
+type minimap struct {
+	rect   image.Rectangle
+	origin f32.Point
+	scale  float32
+}
+
+// BeginNodeEditor adds a node editor to im.  body declares the nodes with Editor.Node.
+func BeginNodeEditor(im *imgio.Im, id string, body func(e *Editor)) {
+	_, id = imgio.GetId(id, "nodeeditor")
+	e := imgio.FromCache(im, id, func() *Editor {
+		return &Editor{
+			nodes:    map[string]*Node{},
+			pins:     map[Pin]*pinState{},
+			selected: map[string]bool{},
+		}
+	})
+	im.PersistValue(id, &e.saved)
+	if e.saved.Positions == nil {
+		e.saved.Positions = map[string]f32.Point{}
+	}
+	e.im = im
+	e.order = e.order[:0]
+	e.created, e.deleted = e.newCreated, e.newDeleted
+	e.newCreated, e.newDeleted = nil, nil
+	body(e)
+	im.AddWidget(e.layout)
+}
+
+// CreatedLinks returns the links the user made since the last frame
+func (e *Editor) CreatedLinks() []Link {
+	return e.created
+}
+
+// DeletedLinks returns the links the user removed since the last frame
+func (e *Editor) DeletedLinks() []Link {
+	return e.deleted
+}
+
+// Selection returns the ids of the selected nodes
+func (e *Editor) Selection() []string {
+	var ids []string
+	for _, n := range e.order {
+		if e.selected[n.id] {
+			ids = append(ids, n.id)
+		}
+	}
+	return ids
+}
+
+// NodePos returns the top left of a node, in pixels in graph space
+func (e *Editor) NodePos(id string) f32.Point {
+	return e.saved.Positions[id]
+}
+
+// SetNodePos moves a node, in pixels in graph space
+func (e *Editor) SetNodePos(id string, p f32.Point) {
+	e.saved.Positions[id] = p
+}
+
+// RemoveNode forgets a node: its position, its state and the links to and from it,
+// which are reported by DeletedLinks.  Nodes that aren't declared are otherwise kept,
+// so one left out for a while comes back as it was.
+func (e *Editor) RemoveNode(id string) {
+	for k := 0; k < len(e.Links); k++ {
+		if e.Links[k].From.Node == id || e.Links[k].To.Node == id {
+			e.deleteLink(k)
+			k--
+		}
+	}
+	delete(e.nodes, id)
+	delete(e.saved.Positions, id)
+	delete(e.selected, id)
+	e.order = slices.DeleteFunc(e.order, func(n *Node) bool { return n.id == id })
+}
+
+func (e *Editor) clearSelection() {
+	clear(e.selected)
+	e.link = nil
+}
+
+func (e *Editor) deleteLink(k int) {
+	if e.link != nil && *e.link == e.Links[k] {
+		e.link = nil
+	}
+	e.newDeleted = append(e.newDeleted, e.Links[k])
+	e.Links = append(e.Links[:k], e.Links[k+1:]...)
+}
+
+// addLink links a and b, whichever way round they are, replacing any link into the input
+func (e *Editor) addLink(a, b *pinState) {
+	if a.output == b.output || a.pin.Node == b.pin.Node {
+		return
+	}
+	if !a.output {
+		a, b = b, a
+	}
+	l := Link{From: a.pin, To: b.pin}
+	if e.CanLink != nil && !e.CanLink(l.From, l.To) {
+		return
+	}
+	for k := 0; k < len(e.Links); k++ {
+		if e.Links[k] == l {
+			return
+		}
+		if e.Links[k].To == l.To {
+			e.deleteLink(k)
+			k--
+		}
+	}
+	e.Links = append(e.Links, l)
+	e.newCreated = append(e.newCreated, l)
+}
+
+// pinAt returns the pin within radius of pos
+func (e *Editor) pinAt(pos f32.Point, radius float32) *pinState {
+	for _, n := range e.order {
+		for _, p := range n.rows {
+			if dist(p.screen, pos) <= radius {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// linkCurve returns the control points of the curve between two pins
+func linkCurve(gtx layout.Context, from, to f32.Point) [4]f32.Point {
+	d := max(abs(to.X-from.X)/2, float32(gtx.Dp(40)))
+	return [4]f32.Point{from, from.Add(f32.Pt(d, 0)), to.Sub(f32.Pt(d, 0)), to}
+}
+
+// linkAt returns the index of the link passing within radius of pos, or -1
+func (e *Editor) linkAt(gtx layout.Context, pos f32.Point, radius float32) int {
+	for k, l := range e.Links {
+		from, ok0 := e.pins[l.From]
+		to, ok1 := e.pins[l.To]
+		if !ok0 || !ok1 {
+			continue
+		}
+		c := linkCurve(gtx, from.screen, to.screen)
+		last := c[0]
+		const steps = 24
+		for s := 1; s <= steps; s++ {
+			pt := bezier(c, float32(s)/steps)
+			if segmentDist(pos, last, pt) <= radius {
+				return k
+			}
+			last = pt
+		}
+	}
+	return -1
+}
+
+func (e *Editor) update(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(
+			pointer.Filter{Target: e, Kinds: pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel},
+			key.FocusFilter{Target: e},
+			key.Filter{Focus: e, Name: key.NameDeleteBackward},
+			key.Filter{Focus: e, Name: key.NameDeleteForward},
+		)
+		if !ok {
+			break
+		}
+		switch ev := ev.(type) {
+		case pointer.Event:
+			switch ev.Kind {
+			case pointer.Press:
+				gtx.Execute(key.FocusCmd{Tag: e})
+				gtx.Execute(pointer.GrabCmd{Tag: e, ID: ev.PointerID})
+				switch {
+				case ev.Buttons.Contain(pointer.ButtonTertiary):
+					e.panning = true
+					e.last = ev.Position
+				case ev.Buttons.Contain(pointer.ButtonPrimary):
+					if k := e.linkAt(gtx, ev.Position, float32(gtx.Dp(5))); k >= 0 {
+						if ev.Modifiers.Contain(key.ModAlt) {
+							e.deleteLink(k)
+						} else {
+							e.clearSelection()
+							l := e.Links[k]
+							e.link = &l
+						}
+						break
+					}
+					if !ev.Modifiers.Contain(key.ModShift) {
+						e.clearSelection()
+					}
+					e.boxing = true
+					e.box = [2]f32.Point{ev.Position, ev.Position}
+				}
+			case pointer.Drag:
+				if e.panning {
+					e.saved.Pan = e.saved.Pan.Add(ev.Position.Sub(e.last))
+					e.last = ev.Position
+				}
+				if e.boxing {
+					e.box[1] = ev.Position
+				}
+			case pointer.Release, pointer.Cancel:
+				if e.boxing {
+					b := e.boxRect()
+					for _, n := range e.order {
+						if n.rect.Overlaps(b) {
+							e.selected[n.id] = true
+						}
+					}
+				}
+				e.panning, e.boxing = false, false
+			}
+		case key.Event:
+			if ev.State == key.Press && e.link != nil {
+				if k := slices.Index(e.Links, *e.link); k >= 0 {
+					e.deleteLink(k)
+				}
+				e.link = nil
+			}
+		}
+		e.im.Invalidate()
+	}
+
+	// the minimap centers the view on the point pressed
+	for {
+		ev, ok := gtx.Event(pointer.Filter{Target: &e.mini, Kinds: pointer.Press | pointer.Drag})
+		if !ok {
+			break
+		}
+		pe, ok := ev.(pointer.Event)
+		if !ok || e.mini.scale == 0 {
+			continue
+		}
+		if pe.Kind == pointer.Press {
+			gtx.Execute(pointer.GrabCmd{Tag: &e.mini, ID: pe.PointerID})
+		}
+		world := e.mini.origin.Add(pe.Position.Sub(layout.FPt(e.mini.rect.Min)).Div(e.mini.scale))
+		e.saved.Pan = layout.FPt(e.size).Mul(0.5).Sub(world)
+		e.im.Invalidate()
+	}
+}
+
+func (e *Editor) boxRect() image.Rectangle {
+	return image.Rectangle{Min: e.box[0].Round(), Max: e.box[1].Round()}.Canon()
+}
+
+func (e *Editor) layout(gtx layout.Context) layout.Dimensions {
+	th := e.im.Theme()
+	height := e.Height
+	if height == 0 {
+		height = 400
+	}
+	size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(height))
+	e.size = size
+	e.update(gtx)
+	for _, n := range e.order {
+		e.updateNode(gtx, n)
+	}
+
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	bg := th.ContrastBg
+	bg.A = 0x10
+	paint.Fill(gtx.Ops, bg)
+	e.drawGrid(gtx)
+	func() {
+		defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+		event.Op(gtx.Ops, e)
+	}()
+
+	// record the nodes first so the links can go underneath them
+	clear(e.pins)
+	var calls []op.CallOp
+	draw := func(selected bool) {
+		for _, n := range e.order {
+			if e.selected[n.id] == selected {
+				m := op.Record(gtx.Ops)
+				n.layout(gtx, e)
+				calls = append(calls, m.Stop())
+			}
+		}
+	}
+	draw(false)
+	draw(true)
+
+	width := float32(gtx.Dp(2))
+	for _, l := range e.Links {
+		from, ok0 := e.pins[l.From]
+		to, ok1 := e.pins[l.To]
+		if !ok0 || !ok1 {
+			continue
+		}
+		c := th.ContrastBg
+		if e.link != nil && l == *e.link {
+			c = th.Fg
+		}
+		drawCurve(gtx, linkCurve(gtx, from.screen, to.screen), c, width)
+	}
+	for _, c := range calls {
+		c.Add(gtx.Ops)
+	}
+	if e.linking && e.from != nil {
+		a, b := e.from.screen, e.linkEnd
+		if !e.from.output {
+			a, b = b, a
+		}
+		drawCurve(gtx, linkCurve(gtx, a, b), th.Fg, width)
+	}
+	if e.boxing {
+		b := e.boxRect()
+		sel := th.ContrastBg
+		sel.A = 0x40
+		paint.FillShape(gtx.Ops, sel, clip.Rect(b).Op())
+		paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{Path: clip.Rect(b).Path(), Width: 1}.Op())
+	}
+	if !e.NoMinimap {
+		e.layoutMinimap(gtx)
+	}
+	return layout.Dimensions{Size: size}
+}
+
+func (e *Editor) drawGrid(gtx layout.Context) {
+	step := gtx.Dp(24)
+	c := e.im.Theme().ContrastBg
+	c.A = 0x20
+	pan := e.saved.Pan.Round()
+	for x := (pan.X%step + step) % step; x < e.size.X; x += step {
+		paint.FillShape(gtx.Ops, c, clip.Rect(image.Rect(x, 0, x+1, e.size.Y)).Op())
+	}
+	for y := (pan.Y%step + step) % step; y < e.size.Y; y += step {
+		paint.FillShape(gtx.Ops, c, clip.Rect(image.Rect(0, y, e.size.X, y+1)).Op())
+	}
+}
+
+func (e *Editor) layoutMinimap(gtx layout.Context) {
+	e.mini.scale = 0
+	if len(e.order) == 0 {
+		return
+	}
+	th := e.im.Theme()
+	pan := e.saved.Pan.Round()
+	// graph space bounds of every node and the view
+	view := image.Rectangle{Max: e.size}.Sub(pan)
+	bounds := view
+	for _, n := range e.order {
+		bounds = bounds.Union(n.rect.Sub(pan))
+	}
+	pad := gtx.Dp(8)
+	mw, mh := e.size.X/5, e.size.Y/5
+	if mw <= 0 || mh <= 0 || bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return
+	}
+	scale := min(float32(mw)/float32(bounds.Dx()), float32(mh)/float32(bounds.Dy()))
+	r := image.Rect(e.size.X-mw-pad, e.size.Y-mh-pad, e.size.X-pad, e.size.Y-pad)
+	e.mini = minimap{rect: r, origin: layout.FPt(bounds.Min), scale: scale}
+	toMini := func(g image.Rectangle) image.Rectangle {
+		min := layout.FPt(g.Min).Sub(e.mini.origin).Mul(scale)
+		max := layout.FPt(g.Max).Sub(e.mini.origin).Mul(scale)
+		return image.Rectangle{Min: min.Round(), Max: max.Round()}.Add(r.Min)
+	}
+
+	defer clip.Rect(r).Push(gtx.Ops).Pop()
+	bg := th.Bg
+	bg.A = 0xd0
+	paint.Fill(gtx.Ops, bg)
+	for _, n := range e.order {
+		c := th.ContrastBg
+		if !e.selected[n.id] {
+			c.A = 0x80
+		}
+		paint.FillShape(gtx.Ops, c, clip.Rect(toMini(n.rect.Sub(pan))).Op())
+	}
+	paint.FillShape(gtx.Ops, th.Fg, clip.Stroke{Path: clip.Rect(toMini(view)).Path(), Width: 1}.Op())
+	paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{Path: clip.Rect(r).Path(), Width: 1}.Op())
+	event.Op(gtx.Ops, &e.mini)
+}
+
+func drawCurve(gtx layout.Context, c [4]f32.Point, col color.NRGBA, width float32) {
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(c[0])
+	p.CubeTo(c[1], c[2], c[3])
+	paint.FillShape(gtx.Ops, col, clip.Stroke{Path: p.End(), Width: width}.Op())
+}
+
+func bezier(c [4]f32.Point, t float32) f32.Point {
+	u := 1 - t
+	return c[0].Mul(u * u * u).
+		Add(c[1].Mul(3 * u * u * t)).
+		Add(c[2].Mul(3 * u * t * t)).
+		Add(c[3].Mul(t * t * t))
+}
+
+// segmentDist is the distance from p to the segment a-b
+func segmentDist(p, a, b f32.Point) float32 {
+	ab := b.Sub(a)
+	l := ab.X*ab.X + ab.Y*ab.Y
+	if l == 0 {
+		return dist(p, a)
+	}
+	ap := p.Sub(a)
+	t := max(0, min(1, (ap.X*ab.X+ap.Y*ab.Y)/l))
+	return dist(p, a.Add(ab.Mul(t)))
+}
+
+func dist(a, b f32.Point) float32 {
+	d := a.Sub(b)
+	return float32(math.Hypot(float64(d.X), float64(d.Y)))
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,200 @@
+package nodes
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+func newTestEditor() *Editor {
+	return &Editor{
+		nodes:    map[string]*Node{},
+		pins:     map[Pin]*pinState{},
+		selected: map[string]bool{},
+		saved:    savedState{Positions: map[string]f32.Point{}},
+	}
+}
+
+func output(node, pin string) *pinState {
+	return &pinState{pin: Pin{Node: node, Pin: pin}, output: true}
+}
+
+func input(node, pin string) *pinState {
+	return &pinState{pin: Pin{Node: node, Pin: pin}}
+}
+
+func link(from, to string) Link {
+	return Link{From: Pin{Node: from, Pin: "out"}, To: Pin{Node: to, Pin: "in"}}
+}
+
+func TestAddLink(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		links   []Link
+		a, b    *pinState
+		canLink func(from, to Pin) bool
+		want    []Link
+		created []Link
+		deleted []Link
+	}{
+		{
+			name:    "output to input",
+			a:       output("a", "out"),
+			b:       input("b", "in"),
+			want:    []Link{link("a", "b")},
+			created: []Link{link("a", "b")},
+		},
+		{
+			name:    "input to output",
+			a:       input("b", "in"),
+			b:       output("a", "out"),
+			want:    []Link{link("a", "b")},
+			created: []Link{link("a", "b")},
+		},
+		{
+			name: "two outputs",
+			a:    output("a", "out"),
+			b:    output("b", "out"),
+		},
+		{
+			name: "same node",
+			a:    output("a", "out"),
+			b:    input("a", "in"),
+		},
+		{
+			name:  "already linked",
+			links: []Link{link("a", "b")},
+			a:     output("a", "out"),
+			b:     input("b", "in"),
+			want:  []Link{link("a", "b")},
+		},
+		{
+			name:    "replaces the input's link",
+			links:   []Link{link("c", "b"), link("a", "d")},
+			a:       output("a", "out"),
+			b:       input("b", "in"),
+			want:    []Link{link("a", "d"), link("a", "b")},
+			created: []Link{link("a", "b")},
+			deleted: []Link{link("c", "b")},
+		},
+		{
+			name:    "refused",
+			a:       output("a", "out"),
+			b:       input("b", "in"),
+			canLink: func(from, to Pin) bool { return from.Node != "a" },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor()
+			e.Links = slices.Clone(tc.links)
+			e.CanLink = tc.canLink
+			e.addLink(tc.a, tc.b)
+			if !slices.Equal(e.Links, tc.want) {
+				t.Errorf("links %v, want %v", e.Links, tc.want)
+			}
+			if !slices.Equal(e.newCreated, tc.created) {
+				t.Errorf("created %v, want %v", e.newCreated, tc.created)
+			}
+			if !slices.Equal(e.newDeleted, tc.deleted) {
+				t.Errorf("deleted %v, want %v", e.newDeleted, tc.deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteLinkClearsSelection(t *testing.T) {
+	e := newTestEditor()
+	e.Links = []Link{link("a", "b"), link("c", "d")}
+	selected := link("c", "d")
+	e.link = &selected
+	e.deleteLink(0)
+	if e.link == nil {
+		t.Fatal("deleting another link cleared the selection")
+	}
+	e.deleteLink(0)
+	if e.link != nil {
+		t.Fatal("deleting the selected link left it selected")
+	}
+	if len(e.Links) != 0 {
+		t.Fatalf("links left: %v", e.Links)
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	e := newTestEditor()
+	e.Links = []Link{link("a", "b"), link("b", "c"), link("c", "d")}
+	for _, id := range []string{"a", "b", "c"} {
+		e.nodes[id] = &Node{id: id}
+		e.saved.Positions[id] = f32.Pt(1, 1)
+		e.selected[id] = true
+	}
+	e.RemoveNode("b")
+	if want := []Link{link("c", "d")}; !slices.Equal(e.Links, want) {
+		t.Fatalf("links %v, want %v", e.Links, want)
+	}
+	if want := []Link{link("a", "b"), link("b", "c")}; !slices.Equal(e.newDeleted, want) {
+		t.Fatalf("deleted %v, want %v", e.newDeleted, want)
+	}
+	_, node := e.nodes["b"]
+	_, pos := e.saved.Positions["b"]
+	if node || pos || e.selected["b"] {
+		t.Fatal("the removed node is still known")
+	}
+	if len(e.nodes) != 2 || len(e.saved.Positions) != 2 {
+		t.Fatal("other nodes were removed")
+	}
+}
+
+func TestSegmentDist(t *testing.T) {
+	a, b := f32.Pt(0, 0), f32.Pt(10, 0)
+	for _, tc := range []struct {
+		p    f32.Point
+		a, b f32.Point
+		want float32
+	}{
+		{f32.Pt(5, 3), a, b, 3},
+		{f32.Pt(5, -4), a, b, 4},
+		{f32.Pt(-3, 4), a, b, 5},
+		{f32.Pt(13, 4), a, b, 5},
+		{f32.Pt(0, 0), a, b, 0},
+		{f32.Pt(3, 4), a, a, 5},
+	} {
+		if got := segmentDist(tc.p, tc.a, tc.b); got != tc.want {
+			t.Errorf("segmentDist(%v, %v, %v) = %v, want %v", tc.p, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestLinkAt(t *testing.T) {
+	e := newTestEditor()
+	// pins level with each other, so the links run straight along y = 0 and y = 100
+	for k, node := range []string{"a", "b", "c", "d"} {
+		p := output(node, "out")
+		if k%2 == 1 {
+			p = input(node, "in")
+		}
+		p.screen = f32.Pt(float32(k%2)*200, float32(k/2)*100)
+		e.pins[p.pin] = p
+	}
+	e.Links = []Link{link("a", "b"), link("c", "d"), link("a", "missing")}
+	gtx := layout.Context{Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1}}
+	for _, tc := range []struct {
+		pos  f32.Point
+		want int
+	}{
+		{f32.Pt(100, 3), 0},
+		{f32.Pt(100, 97), 1},
+		{f32.Pt(100, 50), -1},
+		{f32.Pt(-20, 0), -1},
+	} {
+		t.Run(fmt.Sprint(tc.pos), func(t *testing.T) {
+			if got := e.linkAt(gtx, tc.pos, 5); got != tc.want {
+				t.Fatalf("link %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package nodes
+
+import (
+	"image"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"github.com/bradbev/imgio/src/imgio"
+)
+
+// Node is a box in the graph with a title, a row per pin and a body of Im widgets.
+// Nodes persist between frames, so options can be set once or every frame.
+type Node struct {
+	// Im lays out the widgets of the node's body, below its pins
+	Im *imgio.Im
+	// Width of the node, 160dp when zero
+	Width unit.Dp
+
+	id    string
+	title string
+	pins  map[string]*pinState
+	rows  []*pinState
+
+	// last layout, relative to the editor
+	screen      f32.Point
+	rect        image.Rectangle
+	titleHeight int
+}
+
+type pinState struct {
+	pin    Pin
+	label  string
+	output bool
+	// screen is the pin's center relative to the editor, as of the last layout
+	screen f32.Point
+}
+
+// Node declares a node for this frame.  id keys the node's position and its pins,
+// title is shown in its title bar and body adds its pins and widgets.  A node that
+// isn't declared is hidden but remembered until Editor.RemoveNode.
+func (e *Editor) Node(id, title string, body func(n *Node)) {
+	n, ok := e.nodes[id]
+	if !ok {
+		n = &Node{id: id, pins: map[string]*pinState{}, Im: e.im.UIContext().NewIm()}
+		e.nodes[id] = n
+	}
+	if _, ok := e.saved.Positions[id]; !ok {
+		// cascade new nodes from the top left of the view
+		k := float32(len(e.saved.Positions) % 8)
+		e.saved.Positions[id] = f32.Pt(20+30*k, 20+30*k).Sub(e.saved.Pan)
+	}
+	n.title = title
+	n.rows = n.rows[:0]
+	n.Im.Reset(e.im.Gtx())
+	body(n)
+	e.order = append(e.order, n)
+}
+
+// Input adds a row with an input pin on the left
+func (n *Node) Input(id, label string) {
+	n.pin(id, label, false)
+}
+
+// Output adds a row with an output pin on the right
+func (n *Node) Output(id, label string) {
+	n.pin(id, label, true)
+}
+
+func (n *Node) pin(id, label string, output bool) {
+	p, ok := n.pins[id]
+	if !ok {
+		p = &pinState{pin: Pin{Node: n.id, Pin: id}}
+		n.pins[id] = p
+	}
+	p.label, p.output = label, output
+	n.rows = append(n.rows, p)
+}
+
+func (e *Editor) updateNode(gtx layout.Context, n *Node) {
+	for {
+		ev, ok := gtx.Event(pointer.Filter{Target: n, Kinds: pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel})
+		if !ok {
+			break
+		}
+		pe, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		pos := n.screen.Add(pe.Position)
+		switch pe.Kind {
+		case pointer.Press:
+			if !pe.Buttons.Contain(pointer.ButtonPrimary) {
+				break
+			}
+			e.link = nil
+			switch {
+			case pe.Modifiers.Contain(key.ModShift):
+				e.selected[n.id] = !e.selected[n.id]
+			case !e.selected[n.id]:
+				clear(e.selected)
+				e.selected[n.id] = true
+			}
+			if int(pe.Position.Y) < n.titleHeight && e.selected[n.id] {
+				gtx.Execute(pointer.GrabCmd{Tag: n, ID: pe.PointerID})
+				e.moving = true
+				e.last = pos
+			}
+		case pointer.Drag:
+			if e.moving {
+				d := pos.Sub(e.last)
+				e.last = pos
+				for id := range e.selected {
+					e.saved.Positions[id] = e.saved.Positions[id].Add(d)
+				}
+			}
+		case pointer.Release, pointer.Cancel:
+			e.moving = false
+		}
+		e.im.Invalidate()
+	}
+
+	for _, p := range n.rows {
+		for {
+			ev, ok := gtx.Event(pointer.Filter{Target: p, Kinds: pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel})
+			if !ok {
+				break
+			}
+			pe, ok := ev.(pointer.Event)
+			if !ok {
+				continue
+			}
+			pos := p.screen.Add(pe.Position)
+			switch pe.Kind {
+			case pointer.Press:
+				gtx.Execute(pointer.GrabCmd{Tag: p, ID: pe.PointerID})
+				e.linking, e.from, e.linkEnd = true, p, pos
+				if p.output {
+					break
+				}
+				// dragging away from a linked input picks up its link
+				for k, l := range e.Links {
+					if l.To == p.pin {
+						if from, ok := e.pins[l.From]; ok {
+							e.from = from
+						}
+						e.deleteLink(k)
+						break
+					}
+				}
+			case pointer.Drag:
+				e.linkEnd = pos
+			case pointer.Release:
+				if to := e.pinAt(pos, float32(gtx.Dp(10))); to != nil && e.from != nil {
+					e.addLink(e.from, to)
+				}
+				e.linking, e.from = false, nil
+			case pointer.Cancel:
+				e.linking, e.from = false, nil
+			}
+			e.im.Invalidate()
+		}
+	}
+}
+
+func (n *Node) layout(gtx layout.Context, e *Editor) {
+	th := e.im.Theme()
+	width := n.Width
+	if width == 0 {
+		width = 160
+	}
+	w := gtx.Dp(width)
+	pos := e.saved.Positions[n.id].Add(e.saved.Pan)
+	n.screen = pos
+	defer op.Offset(pos.Round()).Push(gtx.Ops).Pop()
+
+	cgtx := gtx
+	cgtx.Constraints = layout.Constraints{Max: image.Pt(w, gtx.Constraints.Max.Y)}
+	inset := layout.UniformInset(unit.Dp(4))
+	pinInset := layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2), Left: unit.Dp(12), Right: unit.Dp(12)}
+
+	// record the contents first so the background can go underneath
+	content := op.Record(gtx.Ops)
+	title := material.Body1(th, n.title)
+	title.Color = th.ContrastFg
+	y := inset.Layout(cgtx, title.Layout).Size.Y
+	n.titleHeight = y
+	for _, p := range n.rows {
+		dir := layout.W
+		if p.output {
+			dir = layout.E
+		}
+		st := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
+		rgtx := cgtx
+		rgtx.Constraints.Min.X = w
+		h := dir.Layout(rgtx, func(gtx layout.Context) layout.Dimensions {
+			return pinInset.Layout(gtx, material.Body2(th, p.label).Layout)
+		}).Size.Y
+		st.Pop()
+		x := 0
+		if p.output {
+			x = w
+		}
+		p.screen = pos.Add(f32.Pt(float32(x), float32(y+h/2)))
+		e.pins[p.pin] = p
+		y += h
+	}
+	st := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
+	y += n.Im.Layout(cgtx).Size.Y + gtx.Dp(4)
+	st.Pop()
+	call := content.Stop()
+
+	size := image.Pt(w, y)
+	n.rect = image.Rectangle{Max: size}.Add(pos.Round())
+	radius := gtx.Dp(6)
+	func() {
+		defer clip.UniformRRect(image.Rectangle{Max: size}, radius).Push(gtx.Ops).Pop()
+		paint.Fill(gtx.Ops, th.Bg)
+		paint.FillShape(gtx.Ops, th.ContrastBg, clip.Rect{Max: image.Pt(w, n.titleHeight)}.Op())
+		event.Op(gtx.Ops, n)
+	}()
+	call.Add(gtx.Ops)
+	border := th.ContrastBg
+	bw := float32(gtx.Dp(1))
+	if e.selected[n.id] {
+		border, bw = th.Fg, float32(gtx.Dp(2))
+	}
+	paint.FillShape(gtx.Ops, border, clip.Stroke{
+		Path:  clip.UniformRRect(image.Rectangle{Max: size}, radius).Path(gtx.Ops),
+		Width: bw,
+	}.Op())
+
+	// pins go on top of everything so they can be grabbed at the node's edge
+	r := gtx.Dp(5)
+	hit := gtx.Dp(9)
+	for _, p := range n.rows {
+		c := p.screen.Sub(pos).Round()
+		linked := false
+		for _, l := range e.Links {
+			if l.From == p.pin || l.To == p.pin {
+				linked = true
+				break
+			}
+		}
+		dot := clip.Ellipse{Min: c.Sub(image.Pt(r, r)), Max: c.Add(image.Pt(r, r))}
+		paint.FillShape(gtx.Ops, th.Bg, dot.Op(gtx.Ops))
+		if linked {
+			paint.FillShape(gtx.Ops, th.ContrastBg, dot.Op(gtx.Ops))
+		}
+		paint.FillShape(gtx.Ops, th.Fg, clip.Stroke{Path: dot.Path(gtx.Ops), Width: float32(gtx.Dp(1.5))}.Op())
+		func() {
+			defer op.Offset(c).Push(gtx.Ops).Pop()
+			defer clip.Ellipse{Min: image.Pt(-hit, -hit), Max: image.Pt(hit, hit)}.Push(gtx.Ops).Pop()
+			event.Op(gtx.Ops, p)
+		}()
+	}
+}
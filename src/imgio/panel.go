@@ -0,0 +1,52 @@
+package imgio
+
+import (
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+)
+
+// Panel lays out an Im keyed by id as part of an ordinary Gio layout, using the default
+// context.  See Context.Panel.
+func Panel(gtx layout.Context, id string, body func(im *Im)) layout.Dimensions {
+	return gDefault.Panel(gtx, id, body)
+}
+
+// Panel lays out an Im keyed by id as part of an ordinary Gio layout, without a window
+// or the frame lifecycle.  body adds the widgets each time the panel is laid out, and
+// the widget state and persisted values are kept between frames under id.
+func (ctx *Context) Panel(gtx layout.Context, id string, body func(im *Im)) layout.Dimensions {
+	im, ok := ctx.panels[id]
+	if !ok {
+		// panels have no window to show, but keep their persisted values in one.  It
+		// stays out of ctx.windows, so layouts and the z-order never see it.
+		win := &Window{title: panelKey(id)}
+		win.state = ctx.state.current().Widgets[win.title]
+		im = ctx.NewIm()
+		im.window = win
+		win.im = im
+		ctx.panels[id] = im
+	}
+	ctx.unsaved = true
+	ctx.restoreTweaks()
+	ctx.handleUndoKeys(gtx)
+	im.Reset(gtx)
+	body(im)
+	dims := im.Layout(gtx)
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	im.drawList.Layout(gtx)
+	ctx.maybeAutosave()
+	ctx.scheduleAutosave()
+	return dims
+}
+
+// panelKey is the title a panel's persisted values are saved under
+func panelKey(id string) string {
+	return "##panel/" + id
+}
+
+// SetAxis sets the direction the Im stacks its lines in, Vertical unless changed.  A
+// horizontal Im suits toolbars, its widgets keep their natural width unless added with
+// FlexModeFlex.
+func (i *Im) SetAxis(axis layout.Axis) {
+	i.axis = axis
+}
@@ -0,0 +1,70 @@
+package imgio
+
+import (
+	"image/color"
+	"maps"
+	"slices"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+)
+
+// persistFrame draws one frame of ctx with a window persisting the given values
+func persistFrame(ctx *Context, vec *[3]float64, col *color.NRGBA, view *CanvasView) {
+	var ops op.Ops
+	open := true
+	ctx.NewFrame(testContext(&ops))
+	ctx.Begin("persist", &open, func(im *Im) {
+		im.WithPersist(func(im *Im) {
+			im.DragFloat3("vec", vec, 1, 0, 100, "%.1f")
+			im.ColorEdit("col", col)
+		})
+		im.Canvas("canvas", f32.Pt(100, 100), func(c *Canvas) {
+			// the restored view is there before body runs
+			*view = c.View
+			c.Persist = true
+			if view.Zoom == 1 {
+				c.View.Zoom = 3
+			}
+		})
+	})
+	ctx.Render(testContext(&ops))
+}
+
+func TestPersistCallerValues(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx, err := NewContextWithStorage(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec := [3]float64{1, 2, 3}
+	col := color.NRGBA{R: 10, G: 20, B: 30, A: 40}
+	var view CanvasView
+	persistFrame(ctx, &vec, &col, &view)
+	if err := ctx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ids := slices.Sorted(maps.Keys(ctx.state.current().Widgets["persist"]))
+	want := []string{"canvascanvas", "colcoloredit", "vecdragvec"}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("persisted %v, want %v", ids, want)
+	}
+
+	ctx, err = NewContextWithStorage(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vec2 [3]float64
+	var col2 color.NRGBA
+	persistFrame(ctx, &vec2, &col2, &view)
+	if vec2 != vec {
+		t.Errorf("vector restored as %v, want %v", vec2, vec)
+	}
+	if col2 != col {
+		t.Errorf("color restored as %v, want %v", col2, col)
+	}
+	if view.Zoom != 3 {
+		t.Errorf("body saw zoom %v, want the saved 3", view.Zoom)
+	}
+}
@@ -0,0 +1,214 @@
+package plot
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Scale is how an axis maps values onto the plot
+type Scale uint8
+
+const (
+	Linear Scale = iota
+	// Log is base 10, values <= 0 are clamped to a small positive number
+	Log
+	// Time treats values as seconds since the unix epoch and labels ticks with dates and times
+	Time
+)
+
+// AxisId names one of the plot's axes
+type AxisId uint8
+
+const (
+	X1 AxisId = iota
+	Y1
+	Y2
+	Y3
+	numAxes
+)
+
+// Range is a pair of axis limits.  Axes linked to the same Range pan and zoom together,
+// even across plots.
+type Range struct {
+	Min, Max float64
+}
+
+type Axis struct {
+	Range
+	Label string
+	Scale Scale
+	// enabled is true for X1 and Y1, and for Y2/Y3 once they are set up or used
+	enabled bool
+	// fitted is set once the limits have been fitted to data or set explicitly
+	fitted bool
+	link   *Range
+}
+
+const logMin = 1e-300
+
+func (a *Axis) transform(v float64) float64 {
+	if a.Scale == Log {
+		return math.Log10(max(v, logMin))
+	}
+	return v
+}
+
+func (a *Axis) inverse(v float64) float64 {
+	if a.Scale == Log {
+		return math.Pow(10, v)
+	}
+	return v
+}
+
+// norm maps v to [0, 1] across the axis range
+func (a *Axis) norm(v float64) float64 {
+	lo, hi := a.transform(a.Min), a.transform(a.Max)
+	if hi == lo {
+		return 0.5
+	}
+	return (a.transform(v) - lo) / (hi - lo)
+}
+
+// denorm maps t in [0, 1] back to a value on the axis
+func (a *Axis) denorm(t float64) float64 {
+	lo, hi := a.transform(a.Min), a.transform(a.Max)
+	return a.inverse(lo + t*(hi-lo))
+}
+
+// pan shifts the range by dt, in units of the whole range
+func (a *Axis) pan(dt float64) {
+	a.Min, a.Max = a.denorm(dt), a.denorm(1+dt)
+}
+
+// minSpanUlps is how close, in ulps of the centre value, the limits can be zoomed
+// together.  Any closer and neighbouring pixels map to the same value.
+const minSpanUlps = 16
+
+// zoom scales the range by factor about the normalized position c
+func (a *Axis) zoom(c, factor float64) {
+	lo, hi := a.transform(a.Min), a.transform(a.Max)
+	centre := lo + c*(hi-lo)
+	span := (hi - lo) * factor
+	mid := centre + (0.5-c)*span
+	if minSpan := minSpanUlps * ulp(mid); !(math.Abs(span) >= minSpan) {
+		span = math.Copysign(minSpan, hi-lo)
+	}
+	a.Min, a.Max = a.inverse(centre-c*span), a.inverse(centre+(1-c)*span)
+}
+
+// ulp returns the gap between |v| and the next larger float64
+func ulp(v float64) float64 {
+	v = math.Abs(v)
+	return math.Nextafter(v, math.Inf(1)) - v
+}
+
+// setRange sets the limits from two normalized positions
+func (a *Axis) setRange(t0, t1 float64) {
+	a.Min, a.Max = a.denorm(min(t0, t1)), a.denorm(max(t0, t1))
+}
+
+type tick struct {
+	value float64
+	label string
+}
+
+// maxTicks caps the ticks on an axis, whatever its range and step
+const maxTicks = 1000
+
+// tickRange returns the multiples of step within [lo, hi] as the first multiplier and
+// how many there are, at most maxTicks.  A step that is zero, infinite or NaN has none.
+func tickRange(lo, hi, step float64) (first float64, n int) {
+	if !(step > 0) || math.IsInf(step, 0) {
+		return 0, 0
+	}
+	first = math.Ceil(lo / step)
+	count := math.Floor(hi/step) - first + 1
+	if !(count > 0) {
+		return first, 0
+	}
+	return first, int(min(count, maxTicks))
+}
+
+// ticks returns roughly target labelled ticks across the axis
+func (a *Axis) ticks(target int) []tick {
+	lo, hi := min(a.Min, a.Max), max(a.Min, a.Max)
+	if hi <= lo || target < 1 {
+		return nil
+	}
+	var ticks []tick
+	switch a.Scale {
+	case Log:
+		elo, ehi := math.Log10(max(lo, logMin)), math.Log10(max(hi, logMin))
+		step := math.Max(1, math.Ceil((ehi-elo)/float64(target)))
+		first, n := tickRange(elo, ehi, step)
+		for k := range n {
+			v := math.Pow(10, (first+float64(k))*step)
+			ticks = append(ticks, tick{v, strconv.FormatFloat(v, 'g', 3, 64)})
+		}
+	case Time:
+		step := timeStep((hi - lo) / float64(target))
+		layout := "2006-01-02"
+		switch {
+		case step < 1:
+			layout = "15:04:05.000"
+		case step < 60:
+			layout = "15:04:05"
+		case step < 24*60*60:
+			layout = "15:04"
+		}
+		first, n := tickRange(lo, hi, step)
+		for k := range n {
+			v := (first + float64(k)) * step
+			sec, frac := math.Modf(v)
+			t := time.Unix(int64(sec), int64(frac*1e9))
+			ticks = append(ticks, tick{v, t.Format(layout)})
+		}
+	default:
+		step := niceStep((hi - lo) / float64(target))
+		first, n := tickRange(lo, hi, step)
+		for k := range n {
+			v := (first + float64(k)) * step
+			if len(ticks) > 0 && ticks[len(ticks)-1].value == v {
+				// the step is below the precision of v
+				continue
+			}
+			ticks = append(ticks, tick{v, strconv.FormatFloat(v, 'g', 6, 64)})
+		}
+	}
+	return ticks
+}
+
+// niceStep rounds raw up to 1, 2 or 5 times a power of ten
+func niceStep(raw float64) float64 {
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch r := raw / mag; {
+	case r <= 1:
+		return mag
+	case r <= 2:
+		return 2 * mag
+	case r <= 5:
+		return 5 * mag
+	}
+	return 10 * mag
+}
+
+var timeSteps = []float64{
+	1, 2, 5, 10, 15, 30,
+	60, 2 * 60, 5 * 60, 10 * 60, 15 * 60, 30 * 60,
+	3600, 2 * 3600, 3 * 3600, 6 * 3600, 12 * 3600,
+	86400, 2 * 86400, 7 * 86400, 30 * 86400, 365 * 86400,
+}
+
+// timeStep picks a calendar friendly step of at least raw seconds
+func timeStep(raw float64) float64 {
+	if raw < 1 {
+		return niceStep(raw)
+	}
+	for _, s := range timeSteps {
+		if s >= raw {
+			return s
+		}
+	}
+	return niceStep(raw/(365*86400)) * 365 * 86400
+}
@@ -0,0 +1,78 @@
+package plot
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestAxisTicks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		axis   Axis
+		target int
+		want   []float64
+		// max only bounds the count when want is nil
+		max int
+	}{
+		{name: "unit", axis: Axis{Range: Range{0, 1}}, target: 5, want: []float64{0, 0.2, 0.4, 0.6, 0.8, 1}},
+		{name: "reversed", axis: Axis{Range: Range{10, 0}}, target: 2, want: []float64{0, 5, 10}},
+		{name: "offset", axis: Axis{Range: Range{-3, 7}}, target: 5, want: []float64{-2, 0, 2, 4, 6}},
+		{name: "empty", axis: Axis{Range: Range{1, 1}}, target: 5},
+		{name: "no target", axis: Axis{Range: Range{0, 1}}},
+		{name: "log", axis: Axis{Range: Range{1, 1000}, Scale: Log}, target: 3, want: []float64{1, 10, 100, 1000}},
+		{name: "log step", axis: Axis{Range: Range{1, 1e6}, Scale: Log}, target: 3, want: []float64{1, 100, 1e4, 1e6}},
+		{name: "time", axis: Axis{Range: Range{0, 60}, Scale: Time}, target: 4, want: []float64{0, 15, 30, 45, 60}},
+		{name: "infinite", axis: Axis{Range: Range{0, math.Inf(1)}}, target: 5},
+		{name: "infinite log", axis: Axis{Range: Range{1, math.Inf(1)}, Scale: Log}, target: 5, max: maxTicks},
+		{name: "huge", axis: Axis{Range: Range{-math.MaxFloat64, math.MaxFloat64}}, target: 5, max: maxTicks},
+		{name: "below precision", axis: Axis{Range: Range{1e16, 1e16 + 8}}, target: 1000, max: maxTicks},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []float64
+			for _, tk := range tc.axis.ticks(tc.target) {
+				got = append(got, tk.value)
+			}
+			if tc.max > 0 {
+				if len(got) > tc.max {
+					t.Fatalf("got %d ticks, want at most %d", len(got), tc.max)
+				}
+				return
+			}
+			if !slices.EqualFunc(got, tc.want, func(a, b float64) bool {
+				return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+			}) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAxisZoomLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rng   Range
+		scale Scale
+	}{
+		{"zero", Range{-1, 1}, Linear},
+		{"large", Range{1e12, 1e12 + 1}, Linear},
+		{"reversed", Range{5, 4}, Linear},
+		{"log", Range{1, 10}, Log},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Axis{Range: tc.rng, Scale: tc.scale}
+			for range 2000 {
+				a.zoom(0.5, 0.5)
+			}
+			if a.Min == a.Max {
+				t.Fatalf("zoomed to an empty range at %v", a.Min)
+			}
+			if (a.Max > a.Min) != (tc.rng.Max > tc.rng.Min) {
+				t.Fatalf("zoom flipped the range to %v", a.Range)
+			}
+			if len(a.ticks(5)) > maxTicks {
+				t.Fatal("too many ticks")
+			}
+		})
+	}
+}
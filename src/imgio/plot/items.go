@@ -0,0 +1,260 @@
+package plot
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+var seriesColors = []color.NRGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	{R: 0xe3, G: 0x77, B: 0xc2, A: 0xff},
+	{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+}
+
+type bounds struct {
+	xmin, xmax, ymin, ymax float64
+}
+
+func emptyBounds() bounds {
+	inf := math.Inf(1)
+	return bounds{inf, -inf, inf, -inf}
+}
+
+func (b *bounds) add(x, y float64, xAxis, yAxis *Axis) {
+	if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return
+	}
+	if xAxis.Scale != Log || x > 0 {
+		b.xmin, b.xmax = min(b.xmin, x), max(b.xmax, x)
+	}
+	if yAxis.Scale != Log || y > 0 {
+		b.ymin, b.ymax = min(b.ymin, y), max(b.ymax, y)
+	}
+}
+
+type item struct {
+	label string
+	color color.NRGBA
+	yAxis AxisId
+	// fit grows b by the item's data
+	fit func(b *bounds, x, y *Axis)
+	// draw renders the item through d
+	draw func(d *drawer)
+}
+
+// addItem records an item drawn against the current y axis
+func (p *Plot) addItem(label string, fit func(b *bounds, x, y *Axis), draw func(d *drawer)) {
+	c := seriesColors[len(p.items)%len(seriesColors)]
+	p.axes[p.currentY].enabled = true
+	p.items = append(p.items, item{label: label, color: c, yAxis: p.currentY, fit: fit, draw: draw})
+}
+
+func fitXY(xs, ys []float64) func(b *bounds, x, y *Axis) {
+	return func(b *bounds, x, y *Axis) {
+		for k := range min(len(xs), len(ys)) {
+			b.add(xs[k], ys[k], x, y)
+		}
+	}
+}
+
+// PlotLine draws a line through the points xs[k], ys[k].  NaN values break the line.
+func (p *Plot) PlotLine(label string, xs, ys []float64) {
+	p.addItem(label, fitXY(xs, ys), func(d *drawer) {
+		d.stroke(d.polyline(xs, ys, false), d.gtx.Dp(1.5))
+	})
+}
+
+// PlotStairs draws xs, ys as steps, each value held until the next x
+func (p *Plot) PlotStairs(label string, xs, ys []float64) {
+	p.addItem(label, fitXY(xs, ys), func(d *drawer) {
+		d.stroke(d.polyline(xs, ys, true), d.gtx.Dp(1.5))
+	})
+}
+
+// PlotScatter draws a marker at each of the points xs[k], ys[k]
+func (p *Plot) PlotScatter(label string, xs, ys []float64) {
+	p.addItem(label, fitXY(xs, ys), func(d *drawer) {
+		r := float32(d.gtx.Dp(3))
+		for k := range min(len(xs), len(ys)) {
+			pt, ok := d.point(xs[k], ys[k])
+			if !ok {
+				continue
+			}
+			e := clip.Ellipse{
+				Min: image.Pt(int(pt.X-r), int(pt.Y-r)),
+				Max: image.Pt(int(pt.X+r), int(pt.Y+r)),
+			}
+			paint.FillShape(d.gtx.Ops, d.color, e.Op(d.gtx.Ops))
+		}
+	})
+}
+
+// PlotBars draws a bar from zero to ys[k] centred on each xs[k].  width is in x axis units.
+func (p *Plot) PlotBars(label string, xs, ys []float64, width float64) {
+	fit := func(b *bounds, x, y *Axis) {
+		for k := range min(len(xs), len(ys)) {
+			b.add(xs[k]-width/2, ys[k], x, y)
+			b.add(xs[k]+width/2, 0, x, y)
+		}
+	}
+	p.addItem(label, fit, func(d *drawer) {
+		for k := range min(len(xs), len(ys)) {
+			p0, ok0 := d.point(xs[k]-width/2, 0)
+			p1, ok1 := d.point(xs[k]+width/2, ys[k])
+			if !ok0 || !ok1 {
+				continue
+			}
+			d.fillRect(p0, p1, d.color)
+		}
+	})
+}
+
+// PlotShaded fills the region between ys1 and ys2.  A nil ys2 shades down to zero.
+func (p *Plot) PlotShaded(label string, xs, ys1, ys2 []float64) {
+	n := min(len(xs), len(ys1))
+	if ys2 != nil {
+		n = min(n, len(ys2))
+	}
+	lower := func(k int) float64 {
+		if ys2 == nil {
+			return 0
+		}
+		return ys2[k]
+	}
+	fit := func(b *bounds, x, y *Axis) {
+		for k := range n {
+			b.add(xs[k], ys1[k], x, y)
+			b.add(xs[k], lower(k), x, y)
+		}
+	}
+	p.addItem(label, fit, func(d *drawer) {
+		c := d.color
+		c.A = 0x60
+		// one quad per segment so NaNs simply leave gaps
+		for k := 0; k+1 < n; k++ {
+			a0, ok0 := d.point(xs[k], ys1[k])
+			a1, ok1 := d.point(xs[k+1], ys1[k+1])
+			b1, ok2 := d.point(xs[k+1], lower(k+1))
+			b0, ok3 := d.point(xs[k], lower(k))
+			if !ok0 || !ok1 || !ok2 || !ok3 {
+				continue
+			}
+			var path clip.Path
+			path.Begin(d.gtx.Ops)
+			path.MoveTo(a0)
+			path.LineTo(a1)
+			path.LineTo(b1)
+			path.LineTo(b0)
+			path.Close()
+			paint.FillShape(d.gtx.Ops, c, clip.Outline{Path: path.End()}.Op())
+		}
+		d.stroke(d.polyline(xs[:n], ys1[:n], false), d.gtx.Dp(1))
+	})
+}
+
+// PlotHeatmap draws values, rows*cols of them in row major order, as colored cells
+// filling the rectangle from boundsMin to boundsMax.  Row 0 is at the top.  A NaN
+// scaleMin or scaleMax is computed from values.
+func (p *Plot) PlotHeatmap(label string, values []float64, rows, cols int, scaleMin, scaleMax float64, boundsMin, boundsMax [2]float64) {
+	if math.IsNaN(scaleMin) || math.IsNaN(scaleMax) {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			if !math.IsNaN(v) {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+		if math.IsNaN(scaleMin) {
+			scaleMin = lo
+		}
+		if math.IsNaN(scaleMax) {
+			scaleMax = hi
+		}
+	}
+	fit := func(b *bounds, x, y *Axis) {
+		b.add(boundsMin[0], boundsMin[1], x, y)
+		b.add(boundsMax[0], boundsMax[1], x, y)
+	}
+	p.addItem(label, fit, func(d *drawer) {
+		if rows <= 0 || cols <= 0 || len(values) < rows*cols {
+			return
+		}
+		w := (boundsMax[0] - boundsMin[0]) / float64(cols)
+		h := (boundsMax[1] - boundsMin[1]) / float64(rows)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				v := values[r*cols+c]
+				if math.IsNaN(v) {
+					continue
+				}
+				x0 := boundsMin[0] + float64(c)*w
+				y0 := boundsMax[1] - float64(r)*h
+				p0, ok0 := d.point(x0, y0)
+				p1, ok1 := d.point(x0+w, y0-h)
+				if !ok0 || !ok1 {
+					continue
+				}
+				t := 0.5
+				if scaleMax != scaleMin {
+					t = (v - scaleMin) / (scaleMax - scaleMin)
+				}
+				d.fillRect(p0, p1, colormap(t))
+			}
+		}
+	})
+}
+
+// heatStops is a viridis-like color map
+var heatStops = []color.NRGBA{
+	{R: 0x44, G: 0x01, B: 0x54, A: 0xff},
+	{R: 0x3b, G: 0x52, B: 0x8b, A: 0xff},
+	{R: 0x21, G: 0x91, B: 0x8c, A: 0xff},
+	{R: 0x5e, G: 0xc9, B: 0x62, A: 0xff},
+	{R: 0xfd, G: 0xe7, B: 0x25, A: 0xff},
+}
+
+func colormap(t float64) color.NRGBA {
+	t = math.Max(0, math.Min(1, t)) * float64(len(heatStops)-1)
+	k := min(int(t), len(heatStops)-2)
+	f := t - float64(k)
+	a, b := heatStops[k], heatStops[k+1]
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*f) }
+	return color.NRGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 0xff}
+}
+
+// polyline builds a path through the points, skipping NaNs.  stairs holds each value
+// until the next x.
+func (d *drawer) polyline(xs, ys []float64, stairs bool) clip.PathSpec {
+	var path clip.Path
+	path.Begin(d.gtx.Ops)
+	started := false
+	var last f32.Point
+	for k := range min(len(xs), len(ys)) {
+		pt, ok := d.point(xs[k], ys[k])
+		if !ok {
+			started = false
+			continue
+		}
+		switch {
+		case !started:
+			path.MoveTo(pt)
+			started = true
+		case stairs:
+			path.LineTo(f32.Pt(pt.X, last.Y))
+			path.LineTo(pt)
+		default:
+			path.LineTo(pt)
+		}
+		last = pt
+	}
+	return path.End()
+}
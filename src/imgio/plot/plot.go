@@ -0,0 +1,494 @@
+// Package plot draws interactive ImPlot style plots inside an imgio.Im.
+//
+// Left drag pans, the mouse wheel zooms about the pointer, right drag selects a box
+// to zoom to and a double click fits the view to the data.  Clicking a legend entry
+// hides or shows its item.
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"github.com/bradbev/imgio/src/imgio"
+)
+
+const doubleClick = 300 * time.Millisecond
+
+// Plot is an interactive plot, created by BeginPlot and filled with items from its body.
+// The Plot persists between frames, so options can be set once or every frame.
+type Plot struct {
+	// Height of the plot, 250dp when zero
+	Height unit.Dp
+	// NoLegend hides the legend
+	NoLegend bool
+
+	im *imgio.Im
+
+	title    string
+	axes     [numAxes]Axis
+	currentY AxisId
+	items    []item
+	hidden   map[string]bool
+	fitNext  bool
+
+	// interaction state, positions are relative to the plot area
+	area      image.Rectangle
+	panning   bool
+	selecting bool
+	last      f32.Point
+	boxStart  f32.Point
+	boxEnd    f32.Point
+	lastPress time.Duration
+	hovered   bool
+	hover     f32.Point
+	legend    legendState
+}
+
+type legendState struct {
+	rows   []image.Rectangle
+	labels []string
+}
+
+// BeginPlot adds a plot to im.  body adds the plot's items and sets up its axes.
+func BeginPlot(im *imgio.Im, title string, body func(p *Plot)) {
+	label, id := imgio.GetId(title, "plot")
+	p := imgio.FromCache(im, id, func() *Plot {
+		p := &Plot{hidden: map[string]bool{}}
+		for k := range p.axes {
+			p.axes[k].Max = 1
+		}
+		p.axes[X1].enabled = true
+		p.axes[Y1].enabled = true
+		return p
+	})
+	p.im = im
+	p.title = label
+	p.items = nil
+	p.currentY = Y1
+	body(p)
+	im.AddWidget(p.layout)
+}
+
+// SetupAxis labels an axis and sets its scale.  Setting up Y2 or Y3 shows them.
+func (p *Plot) SetupAxis(id AxisId, label string, scale Scale) {
+	a := &p.axes[id]
+	a.Label, a.Scale, a.enabled = label, scale, true
+}
+
+// SetupAxisLimits sets the range of an axis.  Unless always is true the limits only
+// apply until the user pans or zooms.
+func (p *Plot) SetupAxisLimits(id AxisId, min, max float64, always bool) {
+	a := &p.axes[id]
+	if always || !a.fitted {
+		a.Min, a.Max, a.fitted = min, max, true
+		a.push()
+	}
+}
+
+// LinkAxis shares the range of an axis through r.  Every axis linked to the same Range,
+// in this plot or others, pans and zooms together.  Call it every frame.
+func (p *Plot) LinkAxis(id AxisId, r *Range) {
+	a := &p.axes[id]
+	a.link = r
+	if r.Min != r.Max {
+		a.Range, a.fitted = *r, true
+	}
+}
+
+// SetAxis chooses the y axis that following items are drawn against
+func (p *Plot) SetAxis(id AxisId) {
+	if id != X1 {
+		p.currentY = id
+	}
+}
+
+// Fit fits all axes to the visible data on the next frame
+func (p *Plot) Fit() {
+	p.fitNext = true
+}
+
+// push copies the range to the axis link, if there is one
+func (a *Axis) push() {
+	if a.link != nil {
+		*a.link = a.Range
+	}
+}
+
+// fitTo sets the range to cover lo..hi with a little padding
+func (a *Axis) fitTo(lo, hi float64) {
+	tlo, thi := a.transform(lo), a.transform(hi)
+	pad := (thi - tlo) * 0.05
+	if pad == 0 {
+		pad = 0.5
+	}
+	a.Min, a.Max = a.inverse(tlo-pad), a.inverse(thi+pad)
+	a.fitted = true
+	a.push()
+}
+
+// fit sets the range of every axis that needs it from the visible items.
+// all refits axes that already have a range.
+func (p *Plot) fit(all bool) {
+	x := emptyBounds()
+	var ys [numAxes]bounds
+	for k := range ys {
+		ys[k] = emptyBounds()
+	}
+	for _, it := range p.items {
+		if p.hidden[it.label] {
+			continue
+		}
+		b := emptyBounds()
+		it.fit(&b, &p.axes[X1], &p.axes[it.yAxis])
+		x.xmin, x.xmax = min(x.xmin, b.xmin), max(x.xmax, b.xmax)
+		y := &ys[it.yAxis]
+		y.ymin, y.ymax = min(y.ymin, b.ymin), max(y.ymax, b.ymax)
+	}
+	if x.xmin <= x.xmax && (all || !p.axes[X1].fitted) {
+		p.axes[X1].fitTo(x.xmin, x.xmax)
+	}
+	for id := Y1; id < numAxes; id++ {
+		if ys[id].ymin <= ys[id].ymax && (all || !p.axes[id].fitted) {
+			p.axes[id].fitTo(ys[id].ymin, ys[id].ymax)
+		}
+	}
+}
+
+func (p *Plot) yAxes() []AxisId {
+	var ids []AxisId
+	for id := Y1; id < numAxes; id++ {
+		if p.axes[id].enabled {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (p *Plot) update(gtx layout.Context) {
+	w, h := float32(p.area.Dx()), float32(p.area.Dy())
+	if w <= 0 || h <= 0 {
+		return
+	}
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target:  p,
+			Kinds:   pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel | pointer.Scroll | pointer.Move | pointer.Leave,
+			ScrollY: pointer.ScrollRange{Min: math.MinInt32, Max: math.MaxInt32},
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		pos := e.Position.Sub(layout.FPt(p.area.Min))
+		switch e.Kind {
+		case pointer.Press:
+			gtx.Execute(pointer.GrabCmd{Tag: p, ID: e.PointerID})
+			if e.Buttons.Contain(pointer.ButtonSecondary) {
+				p.selecting = true
+				p.boxStart, p.boxEnd = pos, pos
+				break
+			}
+			if e.Time-p.lastPress < doubleClick {
+				p.fitNext = true
+			}
+			p.lastPress = e.Time
+			p.panning = true
+			p.last = pos
+		case pointer.Drag:
+			if p.panning {
+				d := pos.Sub(p.last)
+				p.last = pos
+				p.axes[X1].pan(float64(-d.X / w))
+				for _, id := range p.yAxes() {
+					p.axes[id].pan(float64(d.Y / h))
+				}
+				p.pushAll()
+			}
+			if p.selecting {
+				p.boxEnd = pos
+			}
+		case pointer.Release, pointer.Cancel:
+			if p.selecting && e.Kind == pointer.Release {
+				b := p.box()
+				if b.Dx() > 4 && b.Dy() > 4 {
+					p.axes[X1].setRange(float64(b.Min.X)/float64(w), float64(b.Max.X)/float64(w))
+					for _, id := range p.yAxes() {
+						p.axes[id].setRange(1-float64(b.Max.Y)/float64(h), 1-float64(b.Min.Y)/float64(h))
+					}
+					p.pushAll()
+				}
+			}
+			p.panning, p.selecting = false, false
+		case pointer.Scroll:
+			factor := 0.9
+			if e.Scroll.Y > 0 {
+				factor = 1 / factor
+			}
+			p.axes[X1].zoom(float64(pos.X/w), factor)
+			for _, id := range p.yAxes() {
+				p.axes[id].zoom(1-float64(pos.Y/h), factor)
+			}
+			p.pushAll()
+		case pointer.Move:
+			p.hovered, p.hover = true, pos
+		case pointer.Leave:
+			p.hovered = false
+		}
+		p.im.Invalidate()
+	}
+	for {
+		ev, ok := gtx.Event(pointer.Filter{Target: &p.legend, Kinds: pointer.Press})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		for k, r := range p.legend.rows {
+			if e.Position.Round().In(r) {
+				label := p.legend.labels[k]
+				p.hidden[label] = !p.hidden[label]
+				p.im.Invalidate()
+			}
+		}
+	}
+}
+
+func (p *Plot) pushAll() {
+	for k := range p.axes {
+		p.axes[k].push()
+	}
+}
+
+// box is the selection rectangle, relative to the plot area
+func (p *Plot) box() image.Rectangle {
+	return image.Rectangle{Min: p.boxStart.Round(), Max: p.boxEnd.Round()}.Canon().Intersect(image.Rectangle{Max: p.area.Size()})
+}
+
+func (p *Plot) layout(gtx layout.Context) layout.Dimensions {
+	th := p.im.Theme()
+	height := p.Height
+	if height == 0 {
+		height = 250
+	}
+	size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(height))
+	if p.fitNext {
+		p.fit(true)
+		p.fitNext = false
+	} else {
+		p.fit(false)
+	}
+
+	yAxes := p.yAxes()
+	column := gtx.Dp(55)
+	line := gtx.Dp(unit.Dp(th.TextSize))
+	top := gtx.Dp(6)
+	if p.title != "" || p.axes[Y1].Label != "" {
+		top += line
+	}
+	bottom := line + gtx.Dp(8)
+	if p.axes[X1].Label != "" {
+		bottom += line
+	}
+	p.area = image.Rect(column, top, size.X-gtx.Dp(10)-column*(len(yAxes)-1), size.Y-bottom)
+	p.update(gtx)
+	area := p.area
+
+	fg := th.Fg
+	grid := fg
+	grid.A = 0x30
+
+	paint.FillShape(gtx.Ops, th.Bg, clip.Rect{Max: size}.Op())
+
+	// grid and tick labels
+	xa := &p.axes[X1]
+	for _, t := range xa.ticks(max(area.Dx()/gtx.Dp(90), 2)) {
+		x := area.Min.X + int(xa.norm(t.value)*float64(area.Dx()))
+		paint.FillShape(gtx.Ops, grid, clip.Rect(image.Rect(x, area.Min.Y, x+1, area.Max.Y)).Op())
+		p.drawText(gtx, image.Pt(x, area.Max.Y+gtx.Dp(4)), f32.Pt(0.5, 0), t.label, fg)
+	}
+	for k, id := range yAxes {
+		ya := &p.axes[id]
+		for _, t := range ya.ticks(max(area.Dy()/gtx.Dp(40), 2)) {
+			y := area.Max.Y - int(ya.norm(t.value)*float64(area.Dy()))
+			if k == 0 {
+				paint.FillShape(gtx.Ops, grid, clip.Rect(image.Rect(area.Min.X, y, area.Max.X, y+1)).Op())
+				p.drawText(gtx, image.Pt(area.Min.X-gtx.Dp(4), y), f32.Pt(1, 0.5), t.label, fg)
+			} else {
+				x := area.Max.X + gtx.Dp(4) + column*(k-1)
+				p.drawText(gtx, image.Pt(x, y), f32.Pt(0, 0.5), t.label, seriesAxisColor(k))
+			}
+		}
+		if ya.Label != "" {
+			x := area.Min.X
+			if k > 0 {
+				x = area.Max.X + column*(k-1)
+			}
+			p.drawText(gtx, image.Pt(x, area.Min.Y-gtx.Dp(2)), f32.Pt(0, 1), ya.Label, fg)
+		}
+	}
+	if xa.Label != "" {
+		p.drawText(gtx, image.Pt(area.Min.X+area.Dx()/2, size.Y), f32.Pt(0.5, 1), xa.Label, fg)
+	}
+	if p.title != "" {
+		p.drawText(gtx, image.Pt(size.X/2, 0), f32.Pt(0.5, 0), p.title, fg)
+	}
+
+	// items
+	func() {
+		defer clip.Rect(area).Push(gtx.Ops).Pop()
+		for _, it := range p.items {
+			if p.hidden[it.label] {
+				continue
+			}
+			d := &drawer{gtx: gtx, area: area, x: xa, y: &p.axes[it.yAxis], color: it.color}
+			it.draw(d)
+		}
+		event.Op(gtx.Ops, p)
+	}()
+	paint.FillShape(gtx.Ops, fg, clip.Stroke{Path: clip.Rect(area).Path(), Width: 1}.Op())
+
+	if p.selecting {
+		b := p.box().Add(area.Min)
+		sel := th.ContrastBg
+		sel.A = 0x40
+		paint.FillShape(gtx.Ops, sel, clip.Rect(b).Op())
+		paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{Path: clip.Rect(b).Path(), Width: 1}.Op())
+	}
+	if p.hovered {
+		x := xa.denorm(float64(p.hover.X) / float64(area.Dx()))
+		y := p.axes[Y1].denorm(1 - float64(p.hover.Y)/float64(area.Dy()))
+		readout := fmt.Sprintf("%s, %s", formatValue(xa, x), formatValue(&p.axes[Y1], y))
+		p.drawText(gtx, image.Pt(area.Max.X-gtx.Dp(4), area.Max.Y-gtx.Dp(2)), f32.Pt(1, 1), readout, fg)
+	}
+	if !p.NoLegend {
+		p.layoutLegend(gtx, area)
+	}
+	return layout.Dimensions{Size: size}
+}
+
+func (p *Plot) layoutLegend(gtx layout.Context, area image.Rectangle) {
+	th := p.im.Theme()
+	p.legend.rows = p.legend.rows[:0]
+	p.legend.labels = p.legend.labels[:0]
+	line := gtx.Dp(unit.Dp(th.TextSize))
+	pad := gtx.Dp(4)
+	pos := area.Min.Add(image.Pt(pad, pad))
+	// measure first so the background can go underneath
+	width := 0
+	for _, it := range p.items {
+		if it.label == "" {
+			continue
+		}
+		width = max(width, p.textWidth(gtx, it.label))
+		p.legend.labels = append(p.legend.labels, it.label)
+	}
+	if len(p.legend.labels) == 0 {
+		return
+	}
+	box := image.Rectangle{Min: pos, Max: pos.Add(image.Pt(width+line+3*pad, len(p.legend.labels)*line+2*pad))}
+	bg := th.Bg
+	bg.A = 0xd0
+	paint.FillShape(gtx.Ops, bg, clip.Rect(box).Op())
+	y := box.Min.Y + pad
+	for _, it := range p.items {
+		if it.label == "" {
+			continue
+		}
+		c, fg := it.color, th.Fg
+		if p.hidden[it.label] {
+			c.A, fg.A = 0x40, 0x60
+		}
+		swatch := image.Rect(box.Min.X+pad, y+line/4, box.Min.X+pad+line/2, y+line*3/4)
+		paint.FillShape(gtx.Ops, c, clip.Rect(swatch).Op())
+		p.drawText(gtx, image.Pt(swatch.Max.X+pad, y), f32.Pt(0, 0), it.label, fg)
+		p.legend.rows = append(p.legend.rows, image.Rect(box.Min.X, y, box.Max.X, y+line))
+		y += line
+	}
+	defer clip.Rect(box).Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, &p.legend)
+}
+
+// seriesAxisColor colors the labels of the extra y axes so they can be told apart
+func seriesAxisColor(k int) color.NRGBA {
+	return seriesColors[k%len(seriesColors)]
+}
+
+func formatValue(a *Axis, v float64) string {
+	if a.Scale == Time {
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)).Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprintf("%.4g", v)
+}
+
+// drawer maps plot coordinates to pixels for one item
+type drawer struct {
+	gtx   layout.Context
+	area  image.Rectangle
+	x, y  *Axis
+	color color.NRGBA
+}
+
+// point returns the pixel position of x, y, and false if it can't be drawn
+func (d *drawer) point(x, y float64) (f32.Point, bool) {
+	if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return f32.Point{}, false
+	}
+	if (d.x.Scale == Log && x <= 0) || (d.y.Scale == Log && y <= 0) {
+		return f32.Point{}, false
+	}
+	tx, ty := d.x.norm(x), d.y.norm(y)
+	return f32.Pt(
+		float32(d.area.Min.X)+float32(tx)*float32(d.area.Dx()),
+		float32(d.area.Max.Y)-float32(ty)*float32(d.area.Dy()),
+	), true
+}
+
+func (d *drawer) stroke(path clip.PathSpec, width int) {
+	paint.FillShape(d.gtx.Ops, d.color, clip.Stroke{Path: path, Width: float32(width)}.Op())
+}
+
+func (d *drawer) fillRect(p0, p1 f32.Point, c color.NRGBA) {
+	r := image.Rectangle{Min: p0.Round(), Max: p1.Round()}.Canon()
+	paint.FillShape(d.gtx.Ops, c, clip.Rect(r).Op())
+}
+
+func (p *Plot) label(gtx layout.Context, s string, c color.NRGBA) (layout.Dimensions, op.CallOp) {
+	th := p.im.Theme()
+	macro := op.Record(gtx.Ops)
+	gtx.Constraints = layout.Constraints{Max: image.Pt(1<<16, 1<<16)}
+	l := material.Label(th, th.TextSize*0.8, s)
+	l.Color = c
+	dims := l.Layout(gtx)
+	return dims, macro.Stop()
+}
+
+// drawText draws s so that the point anchor, as a fraction of the text size, lands on pos
+func (p *Plot) drawText(gtx layout.Context, pos image.Point, anchor f32.Point, s string, c color.NRGBA) {
+	dims, call := p.label(gtx, s, c)
+	off := pos.Sub(image.Pt(int(anchor.X*float32(dims.Size.X)), int(anchor.Y*float32(dims.Size.Y))))
+	defer op.Offset(off).Push(gtx.Ops).Pop()
+	call.Add(gtx.Ops)
+}
+
+func (p *Plot) textWidth(gtx layout.Context, s string) int {
+	dims, _ := p.label(gtx, s, color.NRGBA{})
+	return dims.Size.X
+}
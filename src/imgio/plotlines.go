@@ -0,0 +1,186 @@
+package imgio
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+type plotHover struct {
+	hovered bool
+	pos     f32.Point
+}
+
+// PlotLines draws values as a line graph.  offset is the index of the first value to
+// draw, which suits ring buffers.  A NaN scaleMin or scaleMax is computed from values.
+// size is in dp, a zero width fills the line and a zero height is 80dp.
+func (i *Im) PlotLines(label string, values []float32, offset int, overlay string, scaleMin, scaleMax float32, size f32.Point) {
+	i.plot(label, "plotlines", values, offset, overlay, scaleMin, scaleMax, size, false)
+}
+
+// PlotHistogram draws values as bars rising from zero, or from the nearest edge of the
+// scale when zero is outside of it.  The arguments match PlotLines.
+func (i *Im) PlotHistogram(label string, values []float32, offset int, overlay string, scaleMin, scaleMax float32, size f32.Point) {
+	i.plot(label, "plothistogram", values, offset, overlay, scaleMin, scaleMax, size, true)
+}
+
+func (i *Im) plot(label, idType string, values []float32, offset int, overlay string, scaleMin, scaleMax float32, size f32.Point, histogram bool) {
+	label, id := getId(label, idType)
+	hover := fromCache(i, id, func() *plotHover {
+		return &plotHover{}
+	})
+	if isNaN32(scaleMin) || isNaN32(scaleMax) {
+		lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+		for _, v := range values {
+			if !isNaN32(v) {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+		if isNaN32(scaleMin) {
+			scaleMin = lo
+		}
+		if isNaN32(scaleMax) {
+			scaleMax = hi
+		}
+	}
+	n := len(values)
+	at := func(k int) float32 {
+		return values[((k+offset)%n+n)%n]
+	}
+	th := i.theme
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		forEvent(gtx.Source, pointer.Filter{
+			Target: hover,
+			Kinds:  pointer.Move | pointer.Enter | pointer.Leave | pointer.Cancel,
+		}, func(e pointer.Event) bool {
+			hover.hovered = e.Kind == pointer.Move || e.Kind == pointer.Enter
+			hover.pos = e.Position
+			return true
+		})
+
+		sz := image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y)))
+		if sz.X <= 0 {
+			sz.X = gtx.Constraints.Max.X
+		}
+		if sz.Y <= 0 {
+			sz.Y = gtx.Dp(80)
+		}
+		sz = gtx.Constraints.Constrain(sz)
+		defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+		event.Op(gtx.Ops, hover)
+		bg := th.ContrastBg
+		bg.A = 0x20
+		paint.Fill(gtx.Ops, bg)
+
+		w, h := float32(sz.X), float32(sz.Y)
+		toY := func(v float32) float32 {
+			if scaleMax == scaleMin {
+				return h / 2
+			}
+			return h - clamp((v-scaleMin)/(scaleMax-scaleMin), 0, 1)*h
+		}
+		hovered := -1
+		if n > 0 && hover.hovered {
+			if histogram {
+				hovered = clamp(int(hover.pos.X/w*float32(n)), 0, n-1)
+			} else if n > 1 {
+				hovered = clamp(int(math.Round(float64(hover.pos.X/w*float32(n-1)))), 0, n-1)
+			}
+		}
+
+		if histogram && n > 0 {
+			base := toY(clamp(0, min(scaleMin, scaleMax), max(scaleMin, scaleMax)))
+			bw := w / float32(n)
+			for k := 0; k < n; k++ {
+				v := at(k)
+				if isNaN32(v) {
+					continue
+				}
+				y := toY(v)
+				x0, x1 := float32(k)*bw, float32(k+1)*bw-1
+				c := th.ContrastBg
+				if k == hovered {
+					c = th.Fg
+				}
+				r := image.Rect(int(x0), int(min(y, base)), int(max(x1, x0+1)), int(max(y, base)))
+				fillRect(gtx.Ops, r, c)
+			}
+		} else if n > 1 {
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			started := false
+			for k := 0; k < n; k++ {
+				v := at(k)
+				if isNaN32(v) {
+					started = false
+					continue
+				}
+				pt := f32.Pt(float32(k)/float32(n-1)*w, toY(v))
+				if started {
+					p.LineTo(pt)
+				} else {
+					p.MoveTo(pt)
+					started = true
+				}
+			}
+			paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{Path: p.End(), Width: float32(gtx.Dp(1.5))}.Op())
+			if hovered >= 0 && !isNaN32(at(hovered)) {
+				c := image.Pt(int(float32(hovered)/float32(n-1)*w), int(toY(at(hovered))))
+				r := gtx.Dp(3)
+				paint.FillShape(gtx.Ops, th.Fg, clip.Ellipse{Min: c.Sub(image.Pt(r, r)), Max: c.Add(image.Pt(r, r))}.Op(gtx.Ops))
+			}
+		}
+
+		if overlay != "" {
+			lgtx := gtx
+			lgtx.Constraints = layout.Exact(sz)
+			layout.N.Layout(lgtx, material.Label(th, th.TextSize*14.0/16.0, overlay).Layout)
+		}
+		if hovered >= 0 {
+			drawTooltip(gtx, th, hover.pos.Round(), fmt.Sprintf("%d: %.4g", hovered, at(hovered)))
+		}
+		return layout.Dimensions{Size: sz}
+	})
+	if label != "" {
+		i.SameLine()
+		i.Text(label)
+	}
+}
+
+// drawTooltip draws text in a box just below and right of pos, above everything else
+func drawTooltip(gtx layout.Context, th *material.Theme, pos image.Point, text string) {
+	macro := op.Record(gtx.Ops)
+	off := gtx.Dp(12)
+	op.Offset(pos.Add(image.Pt(off, off))).Add(gtx.Ops)
+	tgtx := gtx
+	tgtx.Constraints = layout.Constraints{Max: image.Pt(gtx.Dp(400), gtx.Dp(200))}
+	layout.Background{}.Layout(tgtx,
+		func(gtx layout.Context) layout.Dimensions {
+			defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+			paint.Fill(gtx.Ops, th.Bg)
+			paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{
+				Path:  clip.Rect{Max: gtx.Constraints.Min}.Path(),
+				Width: float32(gtx.Dp(1)),
+			}.Op())
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(3)).Layout(gtx, material.Label(th, th.TextSize*14.0/16.0, text).Layout)
+		},
+	)
+	op.Defer(gtx.Ops, macro.Stop())
+}
+
+func isNaN32(f float32) bool {
+	return f != f
+}
@@ -0,0 +1,76 @@
+package imgio
+
+import (
+	"image"
+
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// popup is a floating Im drawn above everything else.  It is laid out from inside
+// the widget that owns it and closes when the pointer is pressed outside of it.
+type popup struct {
+	ui   *Context
+	open bool
+	im   *Im
+}
+
+// Layout draws the popup at offset from the current position when it is open.
+// body fills the popup's Im, it runs during layout so the popup always sees the
+// same frame as its owner.
+func (p *popup) Layout(gtx layout.Context, offset image.Point, body func(im *Im)) {
+	if !p.open {
+		return
+	}
+	forEvent(gtx.Source, pointer.Filter{
+		Target: p,
+		Kinds:  pointer.Press,
+	}, func(e pointer.Event) bool {
+		p.open = false
+		p.ui.Invalidate()
+		return true
+	})
+	if !p.open {
+		return
+	}
+	if p.im == nil {
+		p.im = p.ui.NewIm()
+	}
+	p.im.Reset(gtx)
+	body(p.im)
+
+	macro := op.Record(gtx.Ops)
+	// catch presses anywhere outside of the popup
+	func() {
+		const far = 1 << 20
+		defer clip.Rect(image.Rect(-far, -far, far, far)).Push(gtx.Ops).Pop()
+		event.Op(gtx.Ops, p)
+	}()
+	func() {
+		defer op.Offset(offset).Push(gtx.Ops).Pop()
+		pgtx := gtx
+		pgtx.Constraints = layout.Constraints{Max: image.Pt(gtx.Dp(360), gtx.Dp(480))}
+		border := widget.Border{Color: p.ui.theme.ContrastBg, Width: unit.Dp(1)}
+		border.Layout(pgtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Background{}.Layout(gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+					paint.Fill(gtx.Ops, p.ui.theme.Bg)
+					// stop presses on the popup itself from reaching the catcher
+					event.Op(gtx.Ops, p.im)
+					return layout.Dimensions{Size: gtx.Constraints.Min}
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(unit.Dp(4)).Layout(gtx, p.im.Layout)
+				},
+			)
+		})
+	}()
+	op.Defer(gtx.Ops, macro.Stop())
+}
@@ -0,0 +1,116 @@
+package imgio
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+const busyPeriod = 1200 * time.Millisecond
+
+// minFrame limits the busy animations to 60 frames a second
+const minFrame = time.Second / 60
+
+// ProgressBar draws fraction, in [0, 1], as a filled bar.  size is in dp, a zero
+// width fills the line and a zero height uses the line height.  overlay is drawn over
+// the bar and defaults to the percentage.  A negative fraction draws an indeterminate bar.
+func (i *Im) ProgressBar(fraction float32, size f32.Point, overlay string) {
+	if overlay == "" && fraction >= 0 {
+		overlay = fmt.Sprintf("%.0f%%", clamp(fraction, 0, 1)*100)
+	}
+	th, ctx := i.theme, i.ctx
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		sz := progressSize(ctx, gtx, size)
+		fill := th.ContrastBg
+		track := fill
+		track.A = 0x40
+		fillRect(gtx.Ops, image.Rectangle{Max: sz}, track)
+		if fraction >= 0 {
+			w := int(clamp(fraction, 0, 1) * float32(sz.X))
+			fillRect(gtx.Ops, image.Rect(0, 0, w, sz.Y), fill)
+		} else {
+			// a block that slides across and back
+			t := phase(gtx.Now, busyPeriod)
+			w := sz.X / 3
+			x := int(float64(sz.X-w) * (1 - math.Abs(2*t-1)))
+			fillRect(gtx.Ops, image.Rect(x, 0, x+w, sz.Y), fill)
+			// the block moves a pixel every step
+			step := busyPeriod / time.Duration(2*max(sz.X-w, 1))
+			gtx.Execute(op.InvalidateCmd{At: nextStep(gtx.Now, max(step, minFrame))})
+		}
+		if overlay != "" {
+			lgtx := gtx
+			lgtx.Constraints = layout.Exact(sz)
+			layout.Center.Layout(lgtx, material.Label(th, th.TextSize*14.0/16.0, overlay).Layout)
+		}
+		return layout.Dimensions{Size: sz}
+	})
+}
+
+// ProgressBarIndeterminate draws a bar for work of unknown length
+func (i *Im) ProgressBarIndeterminate(size f32.Point, overlay string) {
+	i.ProgressBar(-1, size, overlay)
+}
+
+// Spinner draws a rotating ring of dots the height of a line, followed by label
+func (i *Im) Spinner(label string) {
+	th, ctx := i.theme, i.ctx
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		h := ctx.LineHeight(gtx)
+		const dots = 8
+		center := f32.Pt(float32(h)/2, float32(h)/2)
+		r := float32(h) / 2 * 0.7
+		dotR := max(float32(h)/12, 1)
+		lead := int(phase(gtx.Now, busyPeriod) * dots)
+		for k := 0; k < dots; k++ {
+			a := float64(k) / dots * 2 * math.Pi
+			p := center.Add(f32.Pt(r*float32(math.Sin(a)), -r*float32(math.Cos(a))))
+			c := th.ContrastBg
+			// fade the dots trailing the leading one
+			c.A = uint8(255 * (1 - float32((lead-k+dots)%dots)/dots))
+			dot := clip.Ellipse{
+				Min: image.Pt(int(p.X-dotR), int(p.Y-dotR)),
+				Max: image.Pt(int(p.X+dotR), int(p.Y+dotR)),
+			}
+			paint.FillShape(gtx.Ops, c, dot.Op(gtx.Ops))
+		}
+		gtx.Execute(op.InvalidateCmd{At: nextStep(gtx.Now, busyPeriod/dots)})
+		return layout.Dimensions{Size: image.Pt(h, h)}
+	})
+	if label != "" {
+		i.SameLine()
+		i.Text(label)
+	}
+}
+
+func progressSize(ctx *Context, gtx layout.Context, size f32.Point) image.Point {
+	sz := image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y)))
+	if sz.X <= 0 {
+		sz.X = gtx.Constraints.Max.X
+	}
+	if sz.Y <= 0 {
+		sz.Y = ctx.LineHeight(gtx)
+	}
+	return gtx.Constraints.Constrain(sz)
+}
+
+// phase returns where now falls within period, in [0, 1)
+func phase(now time.Time, period time.Duration) float64 {
+	return float64(now.UnixNano()%int64(period)) / float64(period)
+}
+
+// nextStep returns when an animation that changes every step next changes after now,
+// counting steps from the same origin as phase
+func nextStep(now time.Time, step time.Duration) time.Time {
+	n := now.UnixNano()
+	return time.Unix(0, n-n%int64(step)+int64(step))
+}
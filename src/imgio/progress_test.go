@@ -0,0 +1,81 @@
+package imgio
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/op"
+)
+
+// countingApp counts the frames it's asked for
+type countingApp struct {
+	invalidated int
+}
+
+func (a *countingApp) Invalidate() {
+	a.invalidated++
+}
+
+// TestBusyAnimationsWakeUp checks that the busy animations ask for their next frame
+// when they next change, rather than straight away
+func TestBusyAnimationsWakeUp(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		draw func(im *Im)
+		// step is how often the animation changes
+		step time.Duration
+	}{
+		{"spinner", func(im *Im) { im.Spinner("") }, busyPeriod / 8},
+		// the 800px bar's block moves a pixel more often than the frame limit
+		{"bar", func(im *Im) { im.ProgressBarIndeterminate(f32.Point{}, "") }, minFrame},
+		// the 30px bar's block moves 20px in half a period
+		{"short bar", func(im *Im) { im.ProgressBarIndeterminate(f32.Pt(30, 10), "") }, busyPeriod / 40},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := new(countingApp)
+			ctx, err := NewContextWithStorage(app, NewMemoryStorage())
+			if err != nil {
+				t.Fatal(err)
+			}
+			im := ctx.NewIm()
+			var ops op.Ops
+			var r input.Router
+			gtx := testContext(&ops)
+			gtx.Constraints.Min = image.Point{}
+			gtx.Now = time.Unix(100, 0)
+			gtx.Source = r.Source()
+			im.Reset(gtx)
+			tc.draw(im)
+			im.Layout(gtx)
+			r.Frame(&ops)
+			at, ok := r.WakeupTime()
+			if want := nextStep(gtx.Now, tc.step); !ok || !at.Equal(want) {
+				t.Fatalf("next frame in %v (%v), want %v", at.Sub(gtx.Now), ok, want.Sub(gtx.Now))
+			}
+			if app.invalidated != 0 {
+				t.Fatalf("asked for %d frames straight away", app.invalidated)
+			}
+		})
+	}
+}
+
+func TestNextStep(t *testing.T) {
+	// a multiple of the step since the Unix epoch
+	base := time.Unix(90, 0)
+	for _, tc := range []struct {
+		now  time.Duration
+		want time.Duration
+	}{
+		{0, 150 * time.Millisecond},
+		{time.Millisecond, 150 * time.Millisecond},
+		{149 * time.Millisecond, 150 * time.Millisecond},
+		{150 * time.Millisecond, 300 * time.Millisecond},
+	} {
+		if got := nextStep(base.Add(tc.now), 150*time.Millisecond); !got.Equal(base.Add(tc.want)) {
+			t.Errorf("nextStep(+%v) = +%v, want +%v", tc.now, got.Sub(base), tc.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package imgio
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"golang.org/x/exp/constraints"
+)
+
+// ScrollingBuffer is a fixed size ring of timestamped samples.  It is safe to push to
+// from any goroutine, and new samples request a frame from the context drawing the
+// buffer so it stays current.
+type ScrollingBuffer[T constraints.Integer | constraints.Float] struct {
+	mu     sync.Mutex
+	times  []float64
+	values []T
+	next   int
+	full   bool
+	start  time.Time
+	// pending is set by a push and cleared by Snapshot, so a burst of samples only
+	// invalidates once per frame
+	pending atomic.Bool
+	// ui is the context that last drew the buffer, and is asked for new frames
+	ui atomic.Pointer[Context]
+}
+
+func NewScrollingBuffer[T constraints.Integer | constraints.Float](capacity int) *ScrollingBuffer[T] {
+	capacity = max(capacity, 1)
+	return &ScrollingBuffer[T]{
+		times:  make([]float64, capacity),
+		values: make([]T, capacity),
+	}
+}
+
+// Push adds v, timestamped now.  The time is read under the lock, so samples pushed
+// from several goroutines stay in order.
+func (b *ScrollingBuffer[T]) Push(v T) {
+	b.push(time.Now, v)
+}
+
+// PushAt adds v with the given timestamp, overwriting the oldest sample when full
+func (b *ScrollingBuffer[T]) PushAt(t time.Time, v T) {
+	b.push(func() time.Time { return t }, v)
+}
+
+func (b *ScrollingBuffer[T]) push(now func() time.Time, v T) {
+	b.mu.Lock()
+	t := now()
+	if b.start.IsZero() {
+		b.start = t
+	}
+	b.times[b.next] = t.Sub(b.start).Seconds()
+	b.values[b.next] = v
+	b.next++
+	if b.next == len(b.times) {
+		b.next = 0
+		b.full = true
+	}
+	b.mu.Unlock()
+	if ui := b.ui.Load(); ui != nil && !b.pending.Swap(true) {
+		ui.Invalidate()
+	}
+}
+
+// Len returns the number of samples held
+func (b *ScrollingBuffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.full {
+		return len(b.times)
+	}
+	return b.next
+}
+
+// Start returns the time of the first sample, sample times are seconds after it
+func (b *ScrollingBuffer[T]) Start() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.start
+}
+
+// Clear removes all samples
+func (b *ScrollingBuffer[T]) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next, b.full, b.start = 0, false, time.Time{}
+}
+
+// Snapshot appends the samples, oldest first, to times and values and returns them
+func (b *ScrollingBuffer[T]) Snapshot(times []float64, values []T) ([]float64, []T) {
+	b.pending.Store(false)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.full {
+		times = append(times, b.times[b.next:]...)
+		values = append(values, b.values[b.next:]...)
+	}
+	times = append(times, b.times[:b.next]...)
+	values = append(values, b.values[:b.next]...)
+	return times, values
+}
+
+// valueRange returns the range the plot of values is scaled to, which holds every
+// finite value and is never empty
+func valueRange[T constraints.Integer | constraints.Float](values []T) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if f := float64(v); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			lo, hi = min(lo, f), max(hi, f)
+		}
+	}
+	if lo > hi {
+		// nothing finite to show
+		lo, hi = 0, 0
+	}
+	if hi == lo {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	return lo, hi
+}
+
+type scrollingPlotCtx[T constraints.Integer | constraints.Float] struct {
+	paused bool
+	window float64
+	times  []float64
+	values []T
+	hover  plotHover
+}
+
+// PlotScrolling draws the latest samples of buf as a line graph, with a slider for the
+// number of seconds shown and a button that pauses the view while samples keep arriving.
+// size is in dp, a zero width fills the line and a zero height is 80dp.
+func PlotScrolling[T constraints.Integer | constraints.Float](im *Im, label string, buf *ScrollingBuffer[T], size f32.Point) {
+	label, id := getId(label, "plotscrolling")
+	ctx := fromCache(im, id, func() *scrollingPlotCtx[T] {
+		return &scrollingPlotCtx[T]{window: 10}
+	})
+	buf.ui.Store(im.ctx)
+	if !ctx.paused {
+		ctx.times, ctx.values = buf.Snapshot(ctx.times[:0], ctx.values[:0])
+	}
+	im.WithSameLine(func(im *Im) {
+		im.WithFlexMode(FlexModeRigid, func(im *Im) {
+			pause := "Pause"
+			if ctx.paused {
+				pause = "Resume"
+			}
+			if im.Button(pause + "###" + id + "/pause") {
+				ctx.paused = !ctx.paused
+			}
+		})
+		im.SliderFloat("Window (s)##"+id, &ctx.window, 0.5, 60)
+	})
+
+	times, values, window, hover := ctx.times, ctx.values, ctx.window, &ctx.hover
+	th := im.theme
+	im.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		forEvent(gtx.Source, pointer.Filter{
+			Target: hover,
+			Kinds:  pointer.Move | pointer.Enter | pointer.Leave | pointer.Cancel,
+		}, func(e pointer.Event) bool {
+			hover.hovered = e.Kind == pointer.Move || e.Kind == pointer.Enter
+			hover.pos = e.Position
+			return true
+		})
+
+		sz := image.Pt(gtx.Dp(unit.Dp(size.X)), gtx.Dp(unit.Dp(size.Y)))
+		if sz.X <= 0 {
+			sz.X = gtx.Constraints.Max.X
+		}
+		if sz.Y <= 0 {
+			sz.Y = gtx.Dp(80)
+		}
+		sz = gtx.Constraints.Constrain(sz)
+		defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+		event.Op(gtx.Ops, hover)
+		bg := th.ContrastBg
+		bg.A = 0x20
+		paint.Fill(gtx.Ops, bg)
+		if len(times) < 2 {
+			return layout.Dimensions{Size: sz}
+		}
+
+		end := times[len(times)-1]
+		first := sort.SearchFloat64s(times, end-window)
+		lo, hi := valueRange(values[first:])
+		w, h := float32(sz.X), float32(sz.Y)
+		toPt := func(k int) f32.Point {
+			x := (times[k] - (end - window)) / window
+			y := (float64(values[k]) - lo) / (hi - lo)
+			return f32.Pt(float32(x)*w, h-float32(y)*h)
+		}
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		// start one sample early so the line runs off the left edge, and break it
+		// around samples that aren't finite
+		drawing := false
+		for k := max(first-1, 0); k < len(times); k++ {
+			f := float64(values[k])
+			switch {
+			case math.IsNaN(f) || math.IsInf(f, 0):
+				drawing = false
+			case drawing:
+				p.LineTo(toPt(k))
+			default:
+				p.MoveTo(toPt(k))
+				drawing = true
+			}
+		}
+		paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{Path: p.End(), Width: float32(gtx.Dp(1.5))}.Op())
+
+		if hover.hovered {
+			t := end - window + float64(hover.pos.X/w)*window
+			k := clamp(sort.SearchFloat64s(times, t), first, len(times)-1)
+			drawTooltip(gtx, th, hover.pos.Round(), fmt.Sprintf("%.2fs: %v", times[k], values[k]))
+		}
+		return layout.Dimensions{Size: sz}
+	})
+	if label != "" {
+		im.SameLine()
+		im.Text(label)
+	}
+}
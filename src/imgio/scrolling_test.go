@@ -0,0 +1,102 @@
+package imgio
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScrollingBuffer(t *testing.T) {
+	start := time.Unix(1000, 0)
+	for _, tc := range []struct {
+		name       string
+		capacity   int
+		pushes     []int
+		wantTimes  []float64
+		wantValues []int
+	}{
+		{"empty", 3, nil, nil, nil},
+		{"partial", 3, []int{1, 2}, []float64{0, 1}, []int{1, 2}},
+		{"exactly full", 3, []int{1, 2, 3}, []float64{0, 1, 2}, []int{1, 2, 3}},
+		{"wrapped", 3, []int{1, 2, 3, 4, 5}, []float64{2, 3, 4}, []int{3, 4, 5}},
+		{"wrapped twice", 2, []int{1, 2, 3, 4, 5, 6}, []float64{4, 5}, []int{5, 6}},
+		{"zero capacity", 0, []int{1, 2}, []float64{1}, []int{2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewScrollingBuffer[int](tc.capacity)
+			for k, v := range tc.pushes {
+				b.PushAt(start.Add(time.Duration(k)*time.Second), v)
+			}
+			times, values := b.Snapshot(nil, nil)
+			if !slices.Equal(times, tc.wantTimes) || !slices.Equal(values, tc.wantValues) {
+				t.Fatalf("got %v %v, want %v %v", times, values, tc.wantTimes, tc.wantValues)
+			}
+			if b.Len() != len(tc.wantValues) {
+				t.Fatalf("Len %d, want %d", b.Len(), len(tc.wantValues))
+			}
+			if len(tc.pushes) > 0 && !b.Start().Equal(start) {
+				t.Fatalf("Start %v, want %v", b.Start(), start)
+			}
+			b.Clear()
+			if b.Len() != 0 || !b.Start().IsZero() {
+				t.Fatal("Clear left samples behind")
+			}
+		})
+	}
+}
+
+func TestScrollingBufferSnapshotAppends(t *testing.T) {
+	b := NewScrollingBuffer[float32](4)
+	b.PushAt(time.Unix(0, 0), 1)
+	times, values := b.Snapshot([]float64{-1}, []float32{-1})
+	if !slices.Equal(times, []float64{-1, 0}) || !slices.Equal(values, []float32{-1, 1}) {
+		t.Fatalf("got %v %v", times, values)
+	}
+}
+
+// TestScrollingBufferConcurrent pushes from several goroutines, run it with -race
+func TestScrollingBufferConcurrent(t *testing.T) {
+	b := NewScrollingBuffer[int64](64)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				b.Push(int64(k))
+			}
+		}()
+	}
+	for k := 0; k < 10; k++ {
+		b.Snapshot(nil, nil)
+	}
+	wg.Wait()
+	if b.Len() != 64 {
+		t.Fatalf("Len %d, want 64", b.Len())
+	}
+	if times, _ := b.Snapshot(nil, nil); !slices.IsSorted(times) {
+		t.Fatalf("samples out of order: %v", times)
+	}
+}
+
+func TestValueRange(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	for _, tc := range []struct {
+		name   string
+		values []float64
+		lo, hi float64
+	}{
+		{"empty", nil, -0.5, 0.5},
+		{"spread", []float64{3, -1, 2}, -1, 3},
+		{"flat", []float64{2, 2}, 1.5, 2.5},
+		{"nan", []float64{nan, 1, nan, 4}, 1, 4},
+		{"infinite", []float64{-inf, 1, inf, 4}, 1, 4},
+		{"only nan", []float64{nan}, -0.5, 0.5},
+	} {
+		if lo, hi := valueRange(tc.values); lo != tc.lo || hi != tc.hi {
+			t.Errorf("%s: range %v to %v, want %v to %v", tc.name, lo, hi, tc.lo, tc.hi)
+		}
+	}
+}
@@ -3,11 +3,14 @@ package imgio
 import (
 	"fmt"
 	"image"
+	"math"
 
 	"gioui.org/f32"
 	"gioui.org/font"
 	"gioui.org/gesture"
+	"gioui.org/io/event"
 	"gioui.org/io/input"
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -17,44 +20,236 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"golang.org/x/exp/constraints"
 )
 
-type sliderFloatCtx struct {
+// SliderFlags change how a slider maps and accepts its value
+type SliderFlags uint8
+
+const (
+	// SliderLogarithmic maps the slider position logarithmically.  It only applies
+	// when min and max are non-zero and share a sign, otherwise the mapping is linear.
+	SliderLogarithmic SliderFlags = 1 << iota
+	// SliderNoClamp allows values typed with ctrl-click to fall outside min and max
+	SliderNoClamp
+	// SliderVertical lays the slider out vertically, use VSlider to give it a height
+	SliderVertical
+)
+
+type sliderCtx struct {
 	changed bool
-	w       layout.Widget
+	// value, range and setter are rebound every frame so that changes from the caller apply
+	value float64
+	min   float64
+	max   float64
+	flags SliderFlags
+	set   func(float64) float64
+	theme *material.Theme
+	ui    *Context
+	float widget.Float
+	input valueInput
+	w     layout.Widget
 }
 
 // returns true if value changed
 func (i *Im) SliderFloat(label string, float *float64, min, max float64) bool {
-	label, id := getId(label, "sliderfloat")
-	sliderCtx := fromCache(i, id, func() *sliderFloatCtx {
-		scale := max - min
-		f := widget.Float{Value: float32((*float - min) / scale)}
-		s := &sliderFloatCtx{}
-		s.w = func(gtx layout.Context) layout.Dimensions {
-			s.changed = false
-			newVal := float64(f.Value)*scale + min
-			if *float != newVal {
-				*float = newVal
-				gApp.Invalidate()
-				s.changed = true
-			}
-			return material.Slider(i.theme, &f).Layout(gtx)
-		}
-		return s
+	return Slider(i, label, float, min, max, "% 7.3f", 0)
+}
+
+// returns true if value changed
+func (i *Im) SliderFloat32(label string, float *float32, min, max float32) bool {
+	return Slider(i, label, float, min, max, "% 7.3f", 0)
+}
+
+// returns true if value changed
+func (i *Im) SliderInt(label string, value *int64, min, max int64) bool {
+	return Slider(i, label, value, min, max, "%d", 0)
+}
+
+// SliderAngle edits an angle stored in radians, shown in degrees between minDeg and maxDeg.
+// returns true if value changed
+func (i *Im) SliderAngle(label string, rad *float64, minDeg, maxDeg float64) bool {
+	label, id := getId(label, "sliderangle")
+	i.persistWidget(id, rad)
+	deg := *rad * 180 / math.Pi
+	s := i.slider(id, deg, minDeg, maxDeg, 0, func(v float64) float64 {
+		*rad = v * math.Pi / 180
+		return v
+	})
+	recordEdit(i, label, id, rad, s.active(), s.changed)
+	i.sliderLine(label, s, fmt.Sprintf("%.0f deg", deg))
+	return s.changed
+}
+
+// returns true if value changed
+func (i *Im) VSliderFloat(label string, height unit.Dp, float *float64, min, max float64) bool {
+	return VSlider(i, label, height, float, min, max, "%.3f", 0)
+}
+
+// Slider edits v between min and max.  format displays the value, an empty format
+// uses "%d" for integers and "%.3f" for floats.  Like DragVec it is a generic
+// function rather than a method on Im.
+// returns true if value changed
+func Slider[T constraints.Integer | constraints.Float](im *Im, label string, v *T, min, max T, format string, flags SliderFlags) bool {
+	label, id := getId(label, "slider")
+	im.persistWidget(id, v)
+	s := im.slider(id, float64(*v), float64(min), float64(max), flags, sliderSetter(v))
+	recordEdit(im, label, id, v, s.active(), s.changed)
+	im.sliderLine(label, s, fmt.Sprintf(sliderFormat(v, format), *v))
+	return s.changed
+}
+
+// VSlider is a vertical Slider of the given height, with the value shown beneath it
+// returns true if value changed
+func VSlider[T constraints.Integer | constraints.Float](im *Im, label string, height unit.Dp, v *T, min, max T, format string, flags SliderFlags) bool {
+	label, id := getId(label, "vslider")
+	im.persistWidget(id, v)
+	s := im.slider(id, float64(*v), float64(min), float64(max), flags|SliderVertical, sliderSetter(v))
+	recordEdit(im, label, id, v, s.active(), s.changed)
+	value := im.text(sliderFormat(v, format), *v)
+	im.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.Y = gtx.Dp(height)
+				if gtx.Constraints.Max.Y < gtx.Constraints.Min.Y {
+					gtx.Constraints.Max.Y = gtx.Constraints.Min.Y
+				}
+				return s.w(gtx)
+			}),
+			layout.Rigid(value),
+		)
 	})
+	im.SameLine()
+	im.Text(label)
+	return s.changed
+}
+
+func sliderFormat[T constraints.Integer | constraints.Float](v *T, format string) string {
+	if format != "" {
+		return format
+	}
+	half := 0.5
+	if T(half) == 0 {
+		return "%d"
+	}
+	return "%.3f"
+}
+
+// sliderSetter writes slider values back to v, rounding for integer types.
+// The setter returns the value that was actually stored.
+func sliderSetter[T constraints.Integer | constraints.Float](v *T) func(float64) float64 {
+	half := 0.5
+	isInt := T(half) == 0
+	return func(f float64) float64 {
+		if isInt {
+			f = math.Round(f)
+		}
+		*v = T(f)
+		return float64(*v)
+	}
+}
+
+func (i *Im) sliderLine(label string, s *sliderCtx, value string) {
 	i.WithSameLine(func(im *Im) {
-		i.AddWidget(sliderCtx.w)
+		i.AddWidget(s.w)
 		i.WithFlexMode(FlexModeRigid, func(im *Im) {
-			i.Text("%s % 7.3f", label, *float)
+			i.Text("%s %s", label, value)
 		})
 	})
-	return sliderCtx.changed
+}
+
+// slider returns the cached slider for id, bound to value for this frame
+func (i *Im) slider(id string, value, min, max float64, flags SliderFlags, set func(float64) float64) *sliderCtx {
+	s := fromCache(i, id, func() *sliderCtx {
+		s := &sliderCtx{theme: i.theme, ui: i.ctx}
+		s.input.ui = i.ctx
+		s.w = s.layout
+		return s
+	})
+	s.value, s.min, s.max, s.flags, s.set = value, min, max, flags, set
+	return s
+}
+
+func (s *sliderCtx) layout(gtx layout.Context) layout.Dimensions {
+	s.changed = false
+	if s.input.Update(gtx, s.value) {
+		v, changed, dims := s.input.Layout(gtx, s.value)
+		if changed {
+			if s.flags&SliderNoClamp == 0 {
+				v = clamp(v, s.min, s.max)
+			}
+			s.setValue(v)
+		}
+		if !s.input.active {
+			// the slider missed the release of the click that started the edit
+			s.float = widget.Float{}
+		}
+		return dims
+	}
+	if !s.float.Dragging() {
+		s.float.Value = s.toSlider(s.value)
+	}
+	if s.float.Update(gtx) {
+		s.setValue(s.fromSlider(s.float.Value))
+	}
+	style := material.Slider(s.theme, &s.float)
+	if s.flags&SliderVertical != 0 {
+		style.Axis = layout.Vertical
+	}
+	macro := op.Record(gtx.Ops)
+	dims := style.Layout(gtx)
+	call := macro.Stop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	s.input.Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// active returns true while the slider is being dragged or typed into
+func (s *sliderCtx) active() bool {
+	return s.float.Dragging() || s.input.active
+}
+
+func (s *sliderCtx) setValue(v float64) {
+	v = s.set(v)
+	if v != s.value {
+		s.value = v
+		s.changed = true
+		s.ui.Invalidate()
+	}
+}
+
+func (s *sliderCtx) logarithmic() bool {
+	return s.flags&SliderLogarithmic != 0 && s.min*s.max > 0
+}
+
+// toSlider maps a value to the [0, 1] slider position
+func (s *sliderCtx) toSlider(v float64) float32 {
+	if s.max == s.min {
+		return 0
+	}
+	t := (v - s.min) / (s.max - s.min)
+	if s.logarithmic() {
+		lmin, lmax := math.Log(math.Abs(s.min)), math.Log(math.Abs(s.max))
+		t = (math.Log(math.Abs(v)) - lmin) / (lmax - lmin)
+	}
+	return float32(clamp(t, 0, 1))
+}
+
+// fromSlider maps a [0, 1] slider position to a value
+func (s *sliderCtx) fromSlider(t float32) float64 {
+	if s.logarithmic() {
+		lmin, lmax := math.Log(math.Abs(s.min)), math.Log(math.Abs(s.max))
+		return math.Copysign(math.Exp(lmin+float64(t)*(lmax-lmin)), s.min)
+	}
+	return s.min + float64(t)*(s.max-s.min)
 }
 
 type DragFloatCtx struct {
+	ui      *Context
 	w       layout.Widget
 	drag    Drag
+	input   valueInput
 	Changed bool
 	Value   float64
 	Min     float64
@@ -63,12 +258,18 @@ type DragFloatCtx struct {
 
 func (i *Im) DragFloat(label string, value *float64, speed, minv, maxv float64, format string) bool {
 	label, id := getId(label, "dragint")
+	i.persistWidget(id, value)
 	ctx := fromCache(i, id, func() *DragFloatCtx {
-		return MakeDragFloatContext(*value, speed, minv, maxv, func(delta f32.Point, ctx *DragFloatCtx) string {
+		return makeDragFloatContext(i.ctx, *value, speed, minv, maxv, func(delta f32.Point, ctx *DragFloatCtx) string {
 			*value = ctx.Value
 			return fmt.Sprintf(format, *value)
 		})
 	})
+	if ctx.Value != *value {
+		// changed by the caller
+		ctx.Value = *value
+	}
+	recordEdit(i, label, id, value, ctx.active(), ctx.Changed)
 	i.AddWidget(ctx.w)
 	i.SameLine()
 	i.Text(label)
@@ -77,12 +278,18 @@ func (i *Im) DragFloat(label string, value *float64, speed, minv, maxv float64,
 
 func (i *Im) DragFloat32(label string, value *float32, speed, minv, maxv float64, format string) bool {
 	label, id := getId(label, "dragint")
+	i.persistWidget(id, value)
 	ctx := fromCache(i, id, func() *DragFloatCtx {
-		return MakeDragFloatContext(float64(*value), speed, minv, maxv, func(delta f32.Point, ctx *DragFloatCtx) string {
+		return makeDragFloatContext(i.ctx, float64(*value), speed, minv, maxv, func(delta f32.Point, ctx *DragFloatCtx) string {
 			*value = float32(ctx.Value)
 			return fmt.Sprintf(format, ctx.Value)
 		})
 	})
+	if float32(ctx.Value) != *value {
+		// changed by the caller
+		ctx.Value = float64(*value)
+	}
+	recordEdit(i, label, id, value, ctx.active(), ctx.Changed)
 	i.AddWidget(ctx.w)
 	i.SameLine()
 	i.Text(label)
@@ -90,52 +297,158 @@ func (i *Im) DragFloat32(label string, value *float32, speed, minv, maxv float64
 }
 func (i *Im) DragInt(label string, value *int64, speed float64, minv, maxv int64, format string) bool {
 	label, id := getId(label, "dragint")
+	i.persistWidget(id, value)
 	ctx := fromCache(i, id, func() *DragFloatCtx {
 		vf, minf, maxf := float64(*value), float64(minv), float64(maxv)
-		return MakeDragFloatContext(vf, speed, minf, maxf, func(delta f32.Point, ctx *DragFloatCtx) string {
-			*value = int64(ctx.Value)
+		return makeDragFloatContext(i.ctx, vf, speed, minf, maxf, func(delta f32.Point, ctx *DragFloatCtx) string {
+			*value = intBound(ctx.Value)
 			return fmt.Sprintf(format, *value)
 		})
 	})
+	if intBound(ctx.Value) != *value {
+		// changed by the caller
+		ctx.Value = float64(*value)
+	}
+	recordEdit(i, label, id, value, ctx.active(), ctx.Changed)
 	i.AddWidget(ctx.w)
 	return ctx.Changed
 }
 
+// active returns true while the drag is held or typed into
+func (ctx *DragFloatCtx) active() bool {
+	return ctx.drag.Pressed() || ctx.input.active
+}
+
+// MakeDragFloatContext makes the state behind a drag widget, in the default context
 func MakeDragFloatContext(value, speed, minValue, maxValue float64, callback func(delta f32.Point, ctx *DragFloatCtx) string) *DragFloatCtx {
+	return makeDragFloatContext(gDefault, value, speed, minValue, maxValue, callback)
+}
+
+func makeDragFloatContext(ui *Context, value, speed, minValue, maxValue float64, callback func(delta f32.Point, ctx *DragFloatCtx) string) *DragFloatCtx {
 	ctx := &DragFloatCtx{
+		ui:    ui,
 		Value: value,
 	}
+	ctx.drag.theme = &ui.imTheme
+	ctx.input.ui = ui
+	th := ui.theme
 	fnt := font.Font{}
-	size := gTheme.TextSize * 14.0 / 16.0
-	fnt.Typeface = gTheme.Face
+	size := th.TextSize * 14.0 / 16.0
+	fnt.Typeface = th.Face
 	ctx.w = func(gtx layout.Context) layout.Dimensions {
 
-		if ctx.Changed {
-			gApp.Invalidate()
+		if ctx.input.Update(gtx, ctx.Value) {
+			v, changed, dims := ctx.input.Layout(gtx, ctx.Value)
+			ctx.Changed = changed
+			if changed {
+				ctx.Value = clamp(v, minValue, maxValue)
+				callback(f32.Point{}, ctx)
+				ui.Invalidate()
+			}
+			// the drag missed the release of the click that started the edit
+			ctx.drag = Drag{theme: ctx.drag.theme}
+			return dims
 		}
 		delta := ctx.drag.Update(gtx.Metric, gtx.Source, gesture.Horizontal)
-		ctx.Changed = delta.X != 0 || delta.Y != 0
-		ctx.Value = clamp(ctx.Value+float64(delta.X)*speed, minValue, maxValue)
+		if ctx.drag.Pressed() && !gtx.Focused(ctx) {
+			gtx.Execute(key.FocusCmd{Tag: ctx})
+		}
+		steps := ctx.steps(gtx)
+		ctx.Changed = delta.X != 0 || delta.Y != 0 || steps != 0
+		ctx.Value = clamp(ctx.Value+(float64(delta.X)+steps)*speed, minValue, maxValue)
 		str := callback(delta, ctx)
+		if ctx.Changed {
+			// run another frame so the caller sees the change
+			ui.Invalidate()
+		}
+		focused := gtx.Focused(ctx)
 
 		return layout.Background{}.Layout(gtx,
 			func(gtx layout.Context) layout.Dimensions {
 				defer clip.UniformRRect(image.Rectangle{Max: gtx.Constraints.Min}, 0).Push(gtx.Ops).Pop()
-				paint.Fill(gtx.Ops, gTheme.ContrastBg)
+				paint.Fill(gtx.Ops, th.ContrastBg)
+				if focused {
+					widget.Border{Color: th.Fg, Width: unit.Dp(1)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Dimensions{Size: gtx.Constraints.Min}
+					})
+				}
+				event.Op(gtx.Ops, ctx)
 				ctx.drag.Add(gtx.Ops)
+				ctx.input.Add(gtx.Ops)
 				return layout.Dimensions{Size: gtx.Constraints.Min}
 			},
 			func(gtx layout.Context) layout.Dimensions {
 				colMacro := op.Record(gtx.Ops)
-				paint.ColorOp{Color: gTheme.ContrastFg}.Add(gtx.Ops)
-				return widget.Label{Alignment: text.Middle}.Layout(gtx, gTheme.Shaper, fnt, size, str, colMacro.Stop())
+				paint.ColorOp{Color: th.ContrastFg}.Add(gtx.Ops)
+				return widget.Label{Alignment: text.Middle}.Layout(gtx, th.Shaper, fnt, size, str, colMacro.Stop())
 			},
 		)
 	}
 	return ctx
 }
 
+// steps returns how far the mouse wheel and arrow keys moved the value while the
+// drag is focused, in units of its speed
+func (ctx *DragFloatCtx) steps(gtx layout.Context) float64 {
+	var steps float64
+	mods := key.ModShift | key.ModAlt
+	for {
+		ev, ok := gtx.Event(
+			key.FocusFilter{Target: ctx},
+			key.Filter{Focus: ctx, Name: key.NameLeftArrow, Optional: mods},
+			key.Filter{Focus: ctx, Name: key.NameRightArrow, Optional: mods},
+			key.Filter{Focus: ctx, Name: key.NameDownArrow, Optional: mods},
+			key.Filter{Focus: ctx, Name: key.NameUpArrow, Optional: mods},
+		)
+		if !ok {
+			break
+		}
+		e, ok := ev.(key.Event)
+		if !ok || e.State != key.Press {
+			continue
+		}
+		switch e.Name {
+		case key.NameLeftArrow, key.NameDownArrow:
+			steps -= ctx.ui.imTheme.dragMultiplier(e.Modifiers)
+		case key.NameRightArrow, key.NameUpArrow:
+			steps += ctx.ui.imTheme.dragMultiplier(e.Modifiers)
+		}
+	}
+	if !gtx.Focused(ctx) {
+		return steps
+	}
+	forEvent(gtx.Source, pointer.Filter{
+		Target:  ctx,
+		Kinds:   pointer.Scroll,
+		ScrollY: pointer.ScrollRange{Min: math.MinInt32, Max: math.MaxInt32},
+	}, func(e pointer.Event) bool {
+		switch {
+		case e.Scroll.Y < 0:
+			steps += ctx.ui.imTheme.dragMultiplier(e.Modifiers)
+		case e.Scroll.Y > 0:
+			steps -= ctx.ui.imTheme.dragMultiplier(e.Modifiers)
+		}
+		return true
+	})
+	return steps
+}
+
+// dragMultiplier returns the speed multiplier for the held modifiers.
+// Shift drags fast and Alt drags slowly.
+func (t *Theme) dragMultiplier(m key.Modifiers) float64 {
+	switch {
+	case t == nil:
+	case m.Contain(key.ModShift):
+		return t.DragFastMultiplier
+	case m.Contain(key.ModAlt):
+		return t.DragSlowMultiplier
+	}
+	return 1
+}
+
 type Drag struct {
+	// theme gives the modifier speed multipliers, there are none when nil
+	theme      *Theme
 	drag       gesture.Drag
 	startPos   f32.Point
 	currentPos f32.Point
@@ -145,6 +458,19 @@ func (d *Drag) Add(ops *op.Ops) {
 	d.drag.Add(ops)
 }
 
+// Pressed returns true while the pointer is held down on the drag
+func (d *Drag) Pressed() bool {
+	return d.drag.Pressed()
+}
+
+// Dragging returns true while a drag is in progress
+func (d *Drag) Dragging() bool {
+	return d.drag.Dragging()
+}
+
+// Update returns how far the pointer was dragged since the last call, scaled by the
+// modifier multipliers.  The pointer is grabbed, so drags keep going past the edge of
+// the window.
 func (d *Drag) Update(m unit.Metric, q input.Source, axis gesture.Axis) f32.Point {
 	var delta f32.Point
 	for {
@@ -158,8 +484,10 @@ func (d *Drag) Update(m unit.Metric, q input.Source, axis gesture.Axis) f32.Poin
 			d.startPos = e.Position
 			d.currentPos = e.Position
 		case pointer.Drag:
+			// the pointer is grabbed once the drag passes the touch slop, so events keep
+			// arriving when it leaves the widget
 			d.currentPos = e.Position
-			delta = delta.Add(d.currentPos.Sub(lastPos))
+			delta = delta.Add(d.currentPos.Sub(lastPos).Mul(float32(d.theme.dragMultiplier(e.Modifiers))))
 		}
 	}
 	return delta
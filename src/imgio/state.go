@@ -0,0 +1,276 @@
+package imgio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// StateVersion is the version of the saved state format this package writes.  Version 1
+// was a flat map of window title to window, before profiles existed.
+const StateVersion = 2
+
+// DefaultProfile is the profile used until SetProfile picks another
+const DefaultProfile = "default"
+
+// ErrNewerState is returned when the saved state was written by a newer version of the
+// format.  What this version knows of it still loads, but the context won't save over
+// it, which would drop the newer fields.
+var ErrNewerState = errors.New("imgio: state saved by a newer version of the format")
+
+// SavedState is the document a Context saves its windows in
+type SavedState struct {
+	// Version is the format version, StateVersion when written by this package
+	Version int
+	// AppVersion is the application's own version of the state, see Context.Migrate
+	AppVersion int `json:",omitempty"`
+	// Profile names the profile in use
+	Profile  string
+	Profiles map[string]*Profile
+	// Layouts holds the named window arrangements, shared by every profile
+	Layouts map[string]*WindowLayout `json:",omitempty"`
+	// Tweaks holds the values of the variables registered with Tweak, by path
+	Tweaks map[string]json.RawMessage `json:",omitempty"`
+}
+
+// Profile is a named layout: the placement of each window and the values of its
+// persisted widgets, such as tree open flags or tab selection
+type Profile struct {
+	// Windows holds each window's position and size, by title
+	Windows map[string]json.RawMessage
+	// Widgets holds the persisted widget values of each window by title, then widget id
+	Widgets map[string]map[string]json.RawMessage `json:",omitempty"`
+	// Order lists window titles from the back to the front
+	Order []string `json:",omitempty"`
+}
+
+// Migration upgrades the saved state by one application version, see Context.Migrate
+type Migration func(s *SavedState) error
+
+func newSavedState() SavedState {
+	return SavedState{
+		Version:  StateVersion,
+		Profile:  DefaultProfile,
+		Profiles: map[string]*Profile{},
+	}
+}
+
+// current returns the profile in use, creating it if needed
+func (s *SavedState) current() *Profile {
+	if s.Profile == "" {
+		s.Profile = DefaultProfile
+	}
+	p, ok := s.Profiles[s.Profile]
+	if !ok {
+		p = &Profile{}
+		s.Profiles[s.Profile] = p
+	}
+	if p.Windows == nil {
+		p.Windows = map[string]json.RawMessage{}
+	}
+	if p.Widgets == nil {
+		p.Widgets = map[string]map[string]json.RawMessage{}
+	}
+	return p
+}
+
+func (p *Profile) clone() *Profile {
+	c := &Profile{
+		Windows: maps.Clone(p.Windows),
+		Widgets: map[string]map[string]json.RawMessage{},
+		Order:   slices.Clone(p.Order),
+	}
+	for title, w := range p.Widgets {
+		c.Widgets[title] = maps.Clone(w)
+	}
+	return c
+}
+
+// RenameWindow moves the saved placement and widget values of the window titled from to
+// the title to, in every profile
+func (s *SavedState) RenameWindow(from, to string) {
+	for _, p := range s.Profiles {
+		if w, ok := p.Windows[from]; ok {
+			delete(p.Windows, from)
+			p.Windows[to] = w
+		}
+		if w, ok := p.Widgets[from]; ok {
+			delete(p.Widgets, from)
+			p.Widgets[to] = w
+		}
+	}
+}
+
+// decodeState reads a saved state document of any version
+func decodeState(data []byte) (SavedState, error) {
+	s := newSavedState()
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return s, err
+	}
+	if !isV2(doc) {
+		return s, s.fromV1(doc)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+	if s.Profiles == nil {
+		s.Profiles = map[string]*Profile{}
+	}
+	if s.Version > StateVersion {
+		return s, fmt.Errorf("%w: version %d", ErrNewerState, s.Version)
+	}
+	s.Version = StateVersion
+	return s, nil
+}
+
+// isV2 reports whether doc is in the versioned format rather than version 1, which is
+// a map of window titles and so can hold a window titled "Version"
+func isV2(doc map[string]json.RawMessage) bool {
+	var version float64
+	if json.Unmarshal(doc["Version"], &version) != nil {
+		return false
+	}
+	_, profile := doc["Profile"]
+	_, profiles := doc["Profiles"]
+	return profile || profiles
+}
+
+// fromV1 converts the version 1 format, which held only the position and size of each
+// window, by title
+func (s *SavedState) fromV1(doc map[string]json.RawMessage) error {
+	p := s.current()
+	var errs []error
+	for title, raw := range doc {
+		var w Window
+		if err := json.Unmarshal(raw, &w); err != nil {
+			errs = append(errs, fmt.Errorf("window %q: %w", title, err))
+			continue
+		}
+		p.Windows[title], _ = json.Marshal(w)
+	}
+	return errors.Join(errs...)
+}
+
+// Migrate brings the saved state up to the application's version by calling
+// migrations[v] for each version v from the one it was saved at, starting from 0, up to
+// version.  Call it before the first frame, windows already open don't see the changes.
+func (ctx *Context) Migrate(version int, migrations map[int]Migration) error {
+	for v := ctx.state.AppVersion; v < version; v++ {
+		if m, ok := migrations[v]; ok {
+			if err := m(&ctx.state); err != nil {
+				return fmt.Errorf("imgio: migrating state from version %d: %w", v, err)
+			}
+		}
+		ctx.state.AppVersion = v + 1
+	}
+	return nil
+}
+
+// Profile returns the name of the profile in use
+func (ctx *Context) Profile() string {
+	return ctx.state.Profile
+}
+
+// Profiles returns the names of the saved profiles, sorted
+func (ctx *Context) Profiles() []string {
+	ctx.state.current()
+	return slices.Sorted(maps.Keys(ctx.state.Profiles))
+}
+
+// SetProfile switches to the profile called name, moving the open windows and resetting
+// their persisted widgets to its saved values.  A profile that doesn't exist yet starts
+// as a copy of the current layout.
+func (ctx *Context) SetProfile(name string) {
+	if name == ctx.state.Profile {
+		return
+	}
+	ctx.storeWindows()
+	if _, ok := ctx.state.Profiles[name]; !ok {
+		ctx.state.Profiles[name] = ctx.state.current().clone()
+	}
+	ctx.state.Profile = name
+	p := ctx.state.current()
+	for title, win := range ctx.windows {
+		if raw, ok := p.Windows[title]; ok {
+			win.restore(raw)
+		}
+		win.restoreWidgets(p.Widgets[title])
+	}
+	for _, im := range ctx.panels {
+		im.window.restoreWidgets(p.Widgets[im.window.title])
+	}
+	ctx.applyOrder(p.Order)
+	ctx.Invalidate()
+}
+
+// restoreWindow loads the saved placement and widget values of the window title into win
+func (ctx *Context) restoreWindow(title string, win *Window) {
+	p := ctx.state.current()
+	if raw, ok := p.Windows[title]; ok {
+		win.restore(raw)
+	}
+	win.state = p.Widgets[title]
+}
+
+// storeWindows copies the placement and widget values of every window, and the widget
+// values of every panel, into the current profile.  Windows that haven't been opened
+// keep what was loaded for them.
+func (ctx *Context) storeWindows() {
+	p := ctx.state.current()
+	for title, win := range ctx.windows {
+		win.saveState()
+		if raw, err := json.Marshal(win); err == nil {
+			p.Windows[title] = raw
+		}
+		if len(win.state) > 0 {
+			p.Widgets[title] = win.state
+		}
+	}
+	for _, im := range ctx.panels {
+		win := im.window
+		win.saveState()
+		if len(win.state) > 0 {
+			p.Widgets[win.title] = win.state
+		}
+		// saved before panels were kept out of the windows
+		delete(p.Windows, win.title)
+	}
+	p.Order = ctx.windowOrder(p.Order)
+}
+
+// windowOrder merges the z-order of the open windows into saved, an order that may
+// name windows not opened yet.  Those keep their place relative to the windows before
+// them.
+func (ctx *Context) windowOrder(saved []string) []string {
+	var order []string
+	for _, title := range saved {
+		if _, ok := ctx.windows[title]; !ok {
+			order = append(order, title)
+		}
+	}
+	for _, win := range ctx.order {
+		order = append(order, win.title)
+	}
+	return order
+}
+
+// insertWindow adds a new window to the z-order, in front of the windows the current
+// profile has saved behind it
+func (ctx *Context) insertWindow(win *Window) {
+	order := ctx.state.current().Order
+	rank := slices.Index(order, win.title)
+	k := len(ctx.order)
+	if rank >= 0 {
+		for k > 0 {
+			r := slices.Index(order, ctx.order[k-1].title)
+			if r >= 0 && r < rank {
+				break
+			}
+			k--
+		}
+	}
+	ctx.order = slices.Insert(ctx.order, k, win)
+}
@@ -0,0 +1,152 @@
+package imgio
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gioui.org/f32"
+)
+
+func TestDecodeState(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		err     error
+		version int
+		profile string
+		// windows maps a title in the current profile to its expected position
+		windows map[string]float32
+		// widgets maps a title in the current profile to a persisted widget id
+		widgets map[string]string
+	}{
+		{
+			name:    "v1",
+			data:    v1State(map[string]float32{"debug": 10, "other": 3}),
+			version: StateVersion,
+			profile: DefaultProfile,
+			windows: map[string]float32{"debug": 10, "other": 3},
+		},
+		{
+			name:    "v1 with a window titled Version",
+			data:    v1State(map[string]float32{"Version": 1, "Profiles": 2}),
+			version: StateVersion,
+			profile: DefaultProfile,
+			windows: map[string]float32{"Version": 1, "Profiles": 2},
+		},
+		{
+			name:    "v1 empty",
+			data:    `{}`,
+			version: StateVersion,
+			profile: DefaultProfile,
+		},
+		{
+			name: "v2",
+			data: `{"Version": 2, "Profile": "work", "Profiles": {"work": {"Windows": {"a": {"Pos": {"X": 5}}},
+				"Widgets": {"a": {"open": 1}}}}}`,
+			version: StateVersion,
+			profile: "work",
+			windows: map[string]float32{"a": 5},
+			widgets: map[string]string{"a": "open"},
+		},
+		{
+			name:    "v2 without profiles",
+			data:    `{"Version": 2, "Profile": "p"}`,
+			version: StateVersion,
+			profile: "p",
+		},
+		{
+			name:    "newer",
+			data:    `{"Version": 99, "Profile": "p", "Profiles": {"p": {"Windows": {"a": {"Pos": {"X": 7}}}}}, "Future": 1}`,
+			err:     ErrNewerState,
+			version: 99,
+			profile: "p",
+			windows: map[string]float32{"a": 7},
+		},
+		{
+			name: "v1 bad window",
+			data: `{"debug": 5}`,
+			err:  errAny,
+		},
+		{
+			name: "not json",
+			data: `{`,
+			err:  errAny,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := decodeState([]byte(tc.data))
+			switch {
+			case tc.err == errAny:
+				if err == nil {
+					t.Fatal("no error")
+				}
+				return
+			case !errors.Is(err, tc.err):
+				t.Fatalf("error %v, want %v", err, tc.err)
+			}
+			if s.Version != tc.version {
+				t.Errorf("version %d, want %d", s.Version, tc.version)
+			}
+			if s.Profile != tc.profile {
+				t.Errorf("profile %q, want %q", s.Profile, tc.profile)
+			}
+			p := s.current()
+			if len(p.Windows) != len(tc.windows) {
+				t.Errorf("%d windows, want %d", len(p.Windows), len(tc.windows))
+			}
+			for title, x := range tc.windows {
+				var w Window
+				if err := json.Unmarshal(p.Windows[title], &w); err != nil {
+					t.Fatalf("window %q: %v", title, err)
+				}
+				if w.Pos.X != x {
+					t.Errorf("window %q at x %v, want %v", title, w.Pos.X, x)
+				}
+			}
+			for title, id := range tc.widgets {
+				if _, ok := p.Widgets[title][id]; !ok {
+					t.Errorf("window %q lost widget %q", title, id)
+				}
+			}
+		})
+	}
+}
+
+// errAny stands for any error in TestDecodeState
+var errAny = errors.New("any error")
+
+// v1State saves windows at the given x positions the way version 1 did, which wrote
+// the windows by title with only their position and size exported
+func v1State(windows map[string]float32) string {
+	type v1Window struct {
+		Pos  f32.Point
+		Size f32.Point
+	}
+	saved := map[string]*v1Window{}
+	for title, x := range windows {
+		saved[title] = &v1Window{Pos: f32.Pt(x, 20), Size: f32.Pt(300, 200)}
+	}
+	data, _ := json.MarshalIndent(saved, "", " ")
+	return string(data)
+}
+
+func TestNewerStateNotSaved(t *testing.T) {
+	s := NewMemoryStorage()
+	newer := `{"Version": 99, "Profiles": {}, "Future": 1}`
+	s.Save(saveFileName, []byte(newer))
+	ctx, err := NewContextWithStorage(nil, s)
+	if !errors.Is(err, ErrNewerState) {
+		t.Fatalf("loading: %v, want ErrNewerState", err)
+	}
+	if err := ctx.Save(); !errors.Is(err, ErrNewerState) {
+		t.Fatalf("saving: %v, want ErrNewerState", err)
+	}
+	if err := ctx.save(false); err != nil {
+		t.Fatalf("autosaving: %v", err)
+	}
+	data, _ := s.Load(saveFileName)
+	if string(data) != newer {
+		t.Fatalf("state overwritten with %s", data)
+	}
+}
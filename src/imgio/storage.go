@@ -0,0 +1,125 @@
+package imgio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Storage loads and saves the blobs of saved state, such as the window layout and the
+// theme, by key.  Load returns an error matching fs.ErrNotExist for keys never saved.
+type Storage interface {
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+}
+
+// ErrReadOnly is returned when saving to a Storage that can't be written
+var ErrReadOnly = errors.New("imgio: storage is read only")
+
+// FileStorage keeps each key in a file of the same name in Dir
+type FileStorage struct {
+	Dir string
+}
+
+// NewFileStorage returns a Storage keeping its files in dir, which is created on the
+// first save
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{Dir: dir}
+}
+
+// UserConfigStorage returns a FileStorage in the directory named app under the user's
+// configuration directory, see os.UserConfigDir
+func UserConfigStorage(app string) (*FileStorage, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStorage(filepath.Join(dir, app)), nil
+}
+
+func (s *FileStorage) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+func (s *FileStorage) Load(key string) ([]byte, error) {
+	return os.ReadFile(s.path(key))
+}
+
+// Save writes data to a temporary file and renames it over the key's file, so a crash
+// part way through leaves the previous contents intact
+func (s *FileStorage) Save(key string, data []byte) error {
+	path := s.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// MemoryStorage keeps saved state in memory, for tests and for UIs that shouldn't
+// touch the disk
+type MemoryStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{data: map[string][]byte{}}
+}
+
+func (s *MemoryStorage) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "load", Path: key, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *MemoryStorage) Save(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// FSStorage loads saved state from a file system, such as an embed.FS of defaults.
+// Saving returns ErrReadOnly.
+type FSStorage struct {
+	FS fs.FS
+}
+
+func NewFSStorage(fsys fs.FS) *FSStorage {
+	return &FSStorage{FS: fsys}
+}
+
+func (s *FSStorage) Load(key string) ([]byte, error) {
+	return fs.ReadFile(s.FS, key)
+}
+
+func (s *FSStorage) Save(key string, data []byte) error {
+	return ErrReadOnly
+}
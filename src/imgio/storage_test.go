@@ -0,0 +1,106 @@
+package imgio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// testRoundTrip saves and loads through s, checking keys never saved don't exist
+func testRoundTrip(t *testing.T, s Storage) {
+	t.Helper()
+	if _, err := s.Load("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("loading a missing key: %v, want fs.ErrNotExist", err)
+	}
+	for _, data := range []string{"first", "second"} {
+		saved := []byte(data)
+		if err := s.Save("dir/state.json", saved); err != nil {
+			t.Fatal(err)
+		}
+		// the storage keeps its own copy
+		saved[0] = 'X'
+		got, err := s.Load("dir/state.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Fatalf("loaded %q, want %q", got, data)
+		}
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	s := NewFileStorage(dir)
+	testRoundTrip(t, s)
+	entries, err := os.ReadDir(filepath.Join(dir, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		t.Fatalf("left %v behind", entries)
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o644 != 0o644 {
+		t.Fatalf("saved with mode %v", info.Mode())
+	}
+}
+
+func TestFileStorageErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the directory can't be created over a file
+	if err := NewFileStorage(file).Save("state.json", nil); err == nil {
+		t.Fatal("saved under a file")
+	}
+	// the rename fails over a directory, which leaves the previous contents and no
+	// temporary file
+	s := NewFileStorage(dir)
+	if err := os.Mkdir(filepath.Join(dir, "taken"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("taken", []byte("data")); err == nil {
+		t.Fatal("saved over a directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("left %d entries, want the file and the directory", len(entries))
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testRoundTrip(t, NewMemoryStorage())
+	s := NewMemoryStorage()
+	s.Save("key", []byte("data"))
+	got, _ := s.Load("key")
+	got[0] = 'X'
+	if again, _ := s.Load("key"); string(again) != "data" {
+		t.Fatalf("changing a load changed the storage to %q", again)
+	}
+}
+
+func TestFSStorage(t *testing.T) {
+	s := NewFSStorage(fstest.MapFS{"dir/state.json": {Data: []byte("defaults")}})
+	got, err := s.Load("dir/state.json")
+	if err != nil || string(got) != "defaults" {
+		t.Fatalf("loaded %q, %v", got, err)
+	}
+	if _, err := s.Load("missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("loading a missing key: %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Save("dir/state.json", nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("saving: %v, want ErrReadOnly", err)
+	}
+}
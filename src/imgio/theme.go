@@ -1,8 +1,6 @@
 package imgio
 
 import (
-	"sync"
-
 	"gioui.org/layout"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
@@ -12,6 +10,10 @@ type Theme struct {
 	Palette     *material.Palette
 	ButtonInset layout.Inset
 	WidgetInset layout.Inset
+	// DragFastMultiplier scales the speed of drags while Shift is held
+	DragFastMultiplier float64
+	// DragSlowMultiplier scales the speed of drags while Alt is held
+	DragSlowMultiplier float64
 }
 
 // shadowInset exists because we don't have float32 sliders just yet
@@ -37,18 +39,19 @@ func (f *shadowInset) toInset(i *layout.Inset) {
 	i.Right = unit.Dp(f.Right)
 }
 
-var (
-	once        sync.Once
-	buttonInset = &shadowInset{}
-	widgetInset = &shadowInset{}
-)
-
+// ThemeEdit opens a window editing the default context's theme
 func ThemeEdit(open *bool) {
-	once.Do(func() {
-		buttonInset = fromInset(gImTheme.ButtonInset)
-		widgetInset = fromInset(gImTheme.WidgetInset)
+	gDefault.ThemeEdit(open)
+}
+
+// ThemeEdit opens a window editing the context's theme
+func (ctx *Context) ThemeEdit(open *bool) {
+	ctx.themeEditOnce.Do(func() {
+		ctx.buttonInset = fromInset(ctx.imTheme.ButtonInset)
+		ctx.widgetInset = fromInset(ctx.imTheme.WidgetInset)
 	})
-	Begin("Theme Edit", open, func(im *Im) {
+	buttonInset, widgetInset := ctx.buttonInset, ctx.widgetInset
+	ctx.Begin("Theme Edit", open, func(im *Im) {
 		im.SliderFloat("Button Top/Bottom", &buttonInset.Top, 0, 20)
 		buttonInset.Bottom = buttonInset.Top
 		im.DragFloat("Button Left/Right", &buttonInset.Left, 0.1, 0, 20, "%f")
@@ -58,8 +61,10 @@ func ThemeEdit(open *bool) {
 		widgetInset.Bottom = widgetInset.Top
 		im.SliderFloat("Widget Left/Right", &widgetInset.Left, 0, 20)
 		widgetInset.Right = widgetInset.Left
-	})
-	buttonInset.toInset(&gImTheme.ButtonInset)
-	widgetInset.toInset(&gImTheme.WidgetInset)
 
+		im.DragFloat("Drag Fast (Shift)", &ctx.imTheme.DragFastMultiplier, 0.1, 1, 100, "%.1f")
+		im.DragFloat("Drag Slow (Alt)", &ctx.imTheme.DragSlowMultiplier, 0.01, 0.01, 1, "%.2f")
+	})
+	buttonInset.toInset(&ctx.imTheme.ButtonInset)
+	widgetInset.toInset(&ctx.imTheme.WidgetInset)
 }
@@ -0,0 +1,49 @@
+package imgio
+
+import (
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// treeIndent is how far each level of TreeNode indents its body
+const treeIndent = unit.Dp(16)
+
+type treeNode struct {
+	header widget.Clickable
+	Open   bool
+}
+
+// TreeNode adds a header that opens and closes when clicked, calling body to add the
+// indented contents while it is open.  The open flag is saved with the window.
+// returns true if the node is open
+func (i *Im) TreeNode(label string, body func(im *Im)) bool {
+	label, id := getId(label, "treenode")
+	n := fromCache(i, id, func() *treeNode {
+		n := &treeNode{}
+		i.persist(id, &n.Open)
+		return n
+	})
+	if n.header.Clicked(i.gtx) {
+		n.Open = !n.Open
+	}
+	sign := "+ "
+	if n.Open {
+		sign = "- "
+	}
+	th := i.theme
+	i.AddWidget(func(gtx layout.Context) layout.Dimensions {
+		return material.Clickable(gtx, &n.header, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.W.Layout(gtx, material.Body1(th, sign+label).Layout)
+		})
+	})
+	if n.Open {
+		i.indent += treeIndent
+		body(i)
+		i.EndLine()
+		i.indent -= treeIndent
+	}
+	return n.Open
+}
@@ -0,0 +1,256 @@
+package imgio
+
+import (
+	"cmp"
+	"encoding/json"
+	"image/color"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TweakOption configures a variable registered with Tweak
+type TweakOption func(t *tweak)
+
+// Range limits a tweaked number to between min and max
+func Range(min, max float64) TweakOption {
+	return func(t *tweak) {
+		t.min, t.max = min, max
+	}
+}
+
+// Speed sets how much a tweaked number changes for each pixel it is dragged
+func Speed(speed float64) TweakOption {
+	return func(t *tweak) {
+		t.speed = speed
+	}
+}
+
+type tweak struct {
+	path string
+	// value is the registered pointer and def the value it held when registered
+	value    any
+	def      any
+	min, max float64
+	speed    float64
+	// an *int is edited through shadow, synced is the value last written back
+	shadow, synced int64
+}
+
+// tweaks is the process wide registry of tweaked variables, by path.  Only the default
+// context saves and restores them, other contexts can still edit them in their Tweaks
+// window.
+var tweaks = struct {
+	sync.Mutex
+	byPath map[string]*tweak
+	// pending holds the tweaks the default context hasn't restored yet.  Tweak can be
+	// called from any goroutine, so the saved values are applied on the UI's.
+	pending []*tweak
+}{byPath: map[string]*tweak{}}
+
+// Tweak registers the variable v under path, to be edited in the Tweaks window.  v is a
+// pointer to a float64, float32, int, int64, string, bool or color.NRGBA.  The path's
+// segments, separated by /, become the branches of the window's tree.  Registering a
+// path again replaces the variable.  Tweaks can be registered from any package and
+// goroutine at any time.  They take the value saved by the default context when it next
+// starts a frame or saves, if it has one.
+func Tweak(path string, v any, opts ...TweakOption) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return
+	}
+	t := &tweak{
+		path:  path,
+		value: v,
+		def:   rv.Elem().Interface(),
+		min:   math.Inf(-1),
+		max:   math.Inf(1),
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	tweaks.Lock()
+	tweaks.byPath[path] = t
+	tweaks.pending = append(tweaks.pending, t)
+	tweaks.Unlock()
+}
+
+// requeueTweaks marks every registered tweak to be restored again, for a new default
+// context
+func requeueTweaks() {
+	tweaks.Lock()
+	defer tweaks.Unlock()
+	tweaks.pending = tweaks.pending[:0]
+	for _, t := range tweaks.byPath {
+		tweaks.pending = append(tweaks.pending, t)
+	}
+}
+
+// restoreTweaks sets the tweaks registered since the last call to their saved values.
+// Only the default context owns the tweaks' saved values.
+func (ctx *Context) restoreTweaks() {
+	if ctx != gDefault {
+		return
+	}
+	tweaks.Lock()
+	pending := tweaks.pending
+	tweaks.pending = nil
+	tweaks.Unlock()
+	for _, t := range pending {
+		ctx.restoreTweak(t)
+	}
+}
+
+// sortedTweaks returns the registered tweaks sorted by path
+func sortedTweaks() []*tweak {
+	tweaks.Lock()
+	defer tweaks.Unlock()
+	list := make([]*tweak, 0, len(tweaks.byPath))
+	for _, t := range tweaks.byPath {
+		list = append(list, t)
+	}
+	slices.SortFunc(list, func(a, b *tweak) int {
+		return strings.Compare(a.path, b.path)
+	})
+	return list
+}
+
+func (t *tweak) changed() bool {
+	return reflect.ValueOf(t.value).Elem().Interface() != t.def
+}
+
+func (t *tweak) reset() {
+	reflect.ValueOf(t.value).Elem().Set(reflect.ValueOf(t.def))
+}
+
+// restoreTweak sets t to the value saved for its path
+func (ctx *Context) restoreTweak(t *tweak) {
+	if raw, ok := ctx.state.Tweaks[t.path]; ok {
+		json.Unmarshal(raw, t.value)
+	}
+}
+
+// storeTweaks copies the value of every tweak into the default context's saved state
+func (ctx *Context) storeTweaks() {
+	if ctx != gDefault {
+		return
+	}
+	// a tweak not restored yet would overwrite its saved value with its default
+	ctx.restoreTweaks()
+	for _, t := range sortedTweaks() {
+		raw, err := json.Marshal(t.value)
+		if err != nil {
+			continue
+		}
+		if ctx.state.Tweaks == nil {
+			ctx.state.Tweaks = map[string]json.RawMessage{}
+		}
+		ctx.state.Tweaks[t.path] = raw
+	}
+}
+
+// Tweaks opens a window editing the variables registered with Tweak, as a tree of their
+// paths.  Searching lists the matching paths in full.
+func (ctx *Context) Tweaks(open *bool) {
+	ctx.Begin("Tweaks", open, func(im *Im) {
+		search := fromCache(im, "##tweaksearch", func() *string {
+			return new(string)
+		})
+		im.InputText("Search", search)
+		list := sortedTweaks()
+		if *search == "" {
+			tweakTree(im, "", list)
+			return
+		}
+		filter := strings.ToLower(*search)
+		for _, t := range list {
+			if strings.Contains(strings.ToLower(t.path), filter) {
+				tweakWidget(im, t.path, t)
+			}
+		}
+	})
+}
+
+// Tweaks opens the Tweaks window of the default context
+func Tweaks(open *bool) {
+	gDefault.Tweaks(open)
+}
+
+// tweakTree adds list, sorted tweaks whose paths all start with prefix, as a tree
+func tweakTree(im *Im, prefix string, list []*tweak) {
+	for k := 0; k < len(list); {
+		name, _, branch := strings.Cut(strings.TrimPrefix(list[k].path, prefix), "/")
+		if !branch {
+			tweakWidget(im, name, list[k])
+			k++
+			continue
+		}
+		// sorting keeps the paths sharing a prefix together
+		group := prefix + name + "/"
+		end := k + 1
+		for end < len(list) && strings.HasPrefix(list[end].path, group) {
+			end++
+		}
+		children := list[k:end]
+		im.TreeNode(name+"##tweaks/"+group, func(im *Im) {
+			tweakTree(im, group, children)
+		})
+		k = end
+	}
+}
+
+// tweakWidget adds the widget editing t, labelled label, with a button resetting it
+// when it has changed
+func tweakWidget(im *Im, label string, t *tweak) {
+	id := label + "##tweak/" + t.path
+	speed := t.speed
+	im.WithSameLine(func(im *Im) {
+		switch v := t.value.(type) {
+		case *float64:
+			im.DragFloat(id, v, cmp.Or(speed, 0.01), t.min, t.max, "%.3f")
+		case *float32:
+			im.DragFloat32(id, v, cmp.Or(speed, 0.01), t.min, t.max, "%.3f")
+		case *int64:
+			im.DragInt(id, v, cmp.Or(speed, 0.1), intBound(t.min), intBound(t.max), "%d")
+			im.Text(label)
+		case *int:
+			if t.shadow != t.synced {
+				// dragged since the last frame
+				*v = int(t.shadow)
+			} else {
+				t.shadow = int64(*v)
+			}
+			t.synced = t.shadow
+			im.DragInt(id, &t.shadow, cmp.Or(speed, 0.1), intBound(t.min), intBound(t.max), "%d")
+			im.Text(label)
+		case *string:
+			im.InputText(id, v)
+		case *bool:
+			im.Checkbox(id, v)
+		case *color.NRGBA:
+			im.ColorEdit(id, v)
+		default:
+			im.Text("%s: can't tweak %T", label, v)
+		}
+		if t.changed() {
+			im.WithFlexMode(FlexModeRigid, func(im *Im) {
+				if im.Button("Reset##tweakreset/" + t.path) {
+					t.reset()
+				}
+			})
+		}
+	})
+}
+
+// intBound converts a range bound to an int64, keeping infinities at the extremes
+func intBound(f float64) int64 {
+	if f <= math.MinInt64 {
+		return math.MinInt64
+	}
+	if f >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(f)
+}
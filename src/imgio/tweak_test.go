@@ -0,0 +1,43 @@
+package imgio
+
+import (
+	"sync"
+	"testing"
+
+	"gioui.org/op"
+)
+
+// TestTweakRegisteredConcurrently registers tweaks from other goroutines while the
+// default context draws and saves frames, run it with -race.
+func TestTweakRegisteredConcurrently(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Save(saveFileName, []byte(`{"Version": 2, "Profiles": {}, "Tweaks": {"test/saved": 42}}`))
+	ctx, err := NewContextWithStorage(nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setDefault(t, ctx)
+	saved := 1.0
+	var wg sync.WaitGroup
+	for k := 0; k < 4; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var v float64
+			Tweak("test/other", &v)
+		}()
+	}
+	Tweak("test/saved", &saved)
+	var ops op.Ops
+	for range 4 {
+		NewFrame(testContext(&ops))
+		Render(testContext(&ops))
+		if err := ctx.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if saved != 42 {
+		t.Fatalf("tweak restored to %v, want 42", saved)
+	}
+}
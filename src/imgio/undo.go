@@ -0,0 +1,131 @@
+package imgio
+
+import (
+	"gioui.org/io/key"
+	"gioui.org/layout"
+)
+
+// maxUndo is how many actions a context can undo
+const maxUndo = 256
+
+// UndoAction is a change that can be undone and redone
+type UndoAction struct {
+	// Name describes the change, such as the label of the widget that made it
+	Name string
+	Undo func()
+	Redo func()
+}
+
+type undoStack struct {
+	done   []UndoAction
+	undone []UndoAction
+}
+
+// AddUndo records a change made outside the widgets, such as deleting an object, so
+// Undo can call undo and Redo can call redo.  It clears the actions that could be redone.
+func (ctx *Context) AddUndo(name string, undo, redo func()) {
+	u := &ctx.undo
+	u.done = append(u.done, UndoAction{Name: name, Undo: undo, Redo: redo})
+	if len(u.done) > maxUndo {
+		u.done = u.done[len(u.done)-maxUndo:]
+	}
+	u.undone = u.undone[:0]
+}
+
+// Undo reverts the most recent action, returning false when there is none
+func (ctx *Context) Undo() bool {
+	u := &ctx.undo
+	if len(u.done) == 0 {
+		return false
+	}
+	a := u.done[len(u.done)-1]
+	u.done = u.done[:len(u.done)-1]
+	a.Undo()
+	u.undone = append(u.undone, a)
+	ctx.Invalidate()
+	return true
+}
+
+// Redo repeats the most recently undone action, returning false when there is none
+func (ctx *Context) Redo() bool {
+	u := &ctx.undo
+	if len(u.undone) == 0 {
+		return false
+	}
+	a := u.undone[len(u.undone)-1]
+	u.undone = u.undone[:len(u.undone)-1]
+	a.Redo()
+	u.done = append(u.done, a)
+	ctx.Invalidate()
+	return true
+}
+
+// UndoHistory returns the actions that can be undone, oldest first, and those that can
+// be redone, most recently undone last
+func (ctx *Context) UndoHistory() (done, undone []UndoAction) {
+	return ctx.undo.done, ctx.undo.undone
+}
+
+// handleUndoKeys undoes on Ctrl+Z and redoes on Ctrl+Y or Ctrl+Shift+Z.  Focused text
+// editors see the keys first, for their own undo.
+func (ctx *Context) handleUndoKeys(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(
+			key.Filter{Name: "Z", Required: key.ModShortcut, Optional: key.ModShift},
+			key.Filter{Name: "Y", Required: key.ModShortcut},
+		)
+		if !ok {
+			return
+		}
+		e, ok := ev.(key.Event)
+		if !ok || e.State != key.Press {
+			continue
+		}
+		if e.Name == "Y" || e.Modifiers.Contain(key.ModShift) {
+			ctx.Redo()
+		} else {
+			ctx.Undo()
+		}
+	}
+}
+
+// Undo reverts the default context's most recent action
+func Undo() bool {
+	return gDefault.Undo()
+}
+
+// Redo repeats the default context's most recently undone action
+func Redo() bool {
+	return gDefault.Redo()
+}
+
+// AddUndo records a custom action with the default context, see Context.AddUndo
+func AddUndo(name string, undo, redo func()) {
+	gDefault.AddUndo(name, undo, redo)
+}
+
+// undoTracker follows the value a widget edits, to record each edit as one action
+type undoTracker[T comparable] struct {
+	// committed is the value when the widget was last idle
+	committed T
+	edited    bool
+}
+
+// recordEdit adds an undo action for the widget id once an edit of *v is finished.
+// active is true while the widget is in use, such as during a drag, so that the whole
+// gesture becomes one action, and edited is true when the widget changed *v.
+func recordEdit[T comparable](i *Im, name, id string, v *T, active, edited bool) {
+	t := fromCache(i, id+"/undo", func() *undoTracker[T] {
+		return &undoTracker[T]{committed: *v}
+	})
+	t.edited = t.edited || edited
+	if active {
+		return
+	}
+	if t.edited && *v != t.committed {
+		before, after := t.committed, *v
+		i.ctx.AddUndo(name, func() { *v = before }, func() { *v = after })
+	}
+	// changes made while idle came from the caller, or from undo and redo
+	t.committed, t.edited = *v, false
+}
@@ -0,0 +1,108 @@
+package imgio
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUndoStack(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// steps are "set N" to record setting the value to N, "undo" or "redo".  An
+		// undo or redo that has nothing to do is written "undo!" or "redo!".
+		steps      string
+		want       int
+		wantDone   int
+		wantUndone int
+	}{
+		{"nothing", "", 0, 0, 0},
+		{"undo nothing", "undo!", 0, 0, 0},
+		{"redo nothing", "redo!", 0, 0, 0},
+		{"set", "set 1", 1, 1, 0},
+		{"undo", "set 1,set 2,undo", 1, 1, 1},
+		{"undo all", "set 1,set 2,undo,undo,undo!", 0, 0, 2},
+		{"redo", "set 1,set 2,undo,undo,redo", 1, 1, 1},
+		{"redo all", "set 1,undo,redo,redo!", 1, 1, 0},
+		{"set clears redo", "set 1,set 2,undo,set 3,redo!", 3, 2, 0},
+		{"capped", strings.Repeat("set 1,", maxUndo) + "set 2", 2, maxUndo, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+			if err != nil {
+				t.Fatal(err)
+			}
+			value := 0
+			for _, step := range strings.Split(tc.steps, ",") {
+				var n int
+				switch {
+				case step == "":
+				case step == "undo", step == "undo!":
+					if ctx.Undo() != (step == "undo") {
+						t.Fatalf("%s: Undo returned the wrong result", step)
+					}
+				case step == "redo", step == "redo!":
+					if ctx.Redo() != (step == "redo") {
+						t.Fatalf("%s: Redo returned the wrong result", step)
+					}
+				default:
+					if _, err := fmt.Sscanf(step, "set %d", &n); err != nil {
+						t.Fatalf("bad step %q", step)
+					}
+					before := value
+					value = n
+					ctx.AddUndo(step, func() { value = before }, func() { value = n })
+				}
+			}
+			done, undone := ctx.UndoHistory()
+			if value != tc.want || len(done) != tc.wantDone || len(undone) != tc.wantUndone {
+				t.Fatalf("value %d with %d done, %d undone, want %d with %d, %d",
+					value, len(done), len(undone), tc.want, tc.wantDone, tc.wantUndone)
+			}
+		})
+	}
+}
+
+func TestRecordEdit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// frames are the value after each frame, the widget is active for frames
+		// ending in ~ and edited the value in frames starting with *.  A value
+		// without * was changed by the caller.
+		frames []string
+		want   []string
+	}{
+		{"idle", []string{"0", "0"}, nil},
+		{"caller change", []string{"0", "5", "5"}, nil},
+		{"click", []string{"0", "*1"}, []string{"0>1"}},
+		{"drag", []string{"0", "*1~", "*2~", "*3~", "3"}, []string{"0>3"}},
+		{"drag back", []string{"0", "*1~", "*0~", "0"}, nil},
+		{"two edits", []string{"0", "*1", "*2"}, []string{"0>1", "1>2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, err := NewContextWithStorage(nil, NewMemoryStorage())
+			if err != nil {
+				t.Fatal(err)
+			}
+			im := ctx.NewIm()
+			value := 0
+			for _, f := range tc.frames {
+				edited := strings.HasPrefix(f, "*")
+				active := strings.HasSuffix(f, "~")
+				fmt.Sscanf(strings.Trim(f, "*~"), "%d", &value)
+				recordEdit(im, "v", "v", &value, active, edited)
+			}
+			// undo everything, newest first, to see what each action changed
+			var got []string
+			done, _ := ctx.UndoHistory()
+			for range done {
+				after := value
+				ctx.Undo()
+				got = append([]string{fmt.Sprintf("%d>%d", value, after)}, got...)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("recorded %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
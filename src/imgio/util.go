@@ -9,12 +9,19 @@ import (
 	"gioui.org/io/event"
 	"gioui.org/io/input"
 	"gioui.org/layout"
+	"gioui.org/widget/material"
 	"golang.org/x/exp/constraints"
 )
 
+// LineHeight is the height of a button in the default context
 func LineHeight(gtx layout.Context) int {
-	b := gImTheme.ButtonInset
-	return gtx.Dp(gtx.Metric.SpToDp(gTheme.TextSize) + b.Top + b.Bottom)
+	return gDefault.LineHeight(gtx)
+}
+
+// LineHeight is the height of a button
+func (ctx *Context) LineHeight(gtx layout.Context) int {
+	b := ctx.imTheme.ButtonInset
+	return gtx.Dp(gtx.Metric.SpToDp(ctx.theme.TextSize) + b.Top + b.Bottom)
 }
 
 var findHashes = regexp.MustCompile("(.*?)(##.*)").FindStringSubmatch
@@ -51,6 +58,50 @@ func forEvent[T any](s input.Source, filter event.Filter, body func(evt T) bool)
 	}
 }
 
+// GetId splits an imgui style label into the text to show and the id to cache under,
+// for packages that build widgets on top of Im
+func GetId(str, idType string) (label, id string) {
+	return getId(str, idType)
+}
+
+// FromCache returns the value cached in i under key, creating it with makeValue on
+// first use.  It lets packages that build widgets on top of Im keep state between frames.
+func FromCache[T any](i *Im, key string, makeValue func() T) T {
+	return fromCache(i, key, makeValue)
+}
+
+// Gtx returns the layout context of the current frame, for packages that build their own
+// Im inside a widget
+func (i *Im) Gtx() layout.Context {
+	return i.gtx
+}
+
+// UIContext returns the imgio Context the Im belongs to
+func (i *Im) UIContext() *Context {
+	return i.ctx
+}
+
+// Theme returns the material theme the Im draws with
+func (i *Im) Theme() *material.Theme {
+	return i.theme
+}
+
+// Invalidate requests another frame from the Im's context
+func (i *Im) Invalidate() {
+	i.ctx.Invalidate()
+}
+
+// PersistValue loads v from the window's saved state the first time id is seen, and
+// saves v with the window from then on.  v must be a pointer.
+func (i *Im) PersistValue(id string, v any) {
+	i.persist(id, v)
+}
+
+// Invalidate requests another frame from the App passed to Init
+func Invalidate() {
+	gDefault.Invalidate()
+}
+
 func fromCache[T any](i *Im, key string, makeValue func() T) T {
 	item, exists := i.widgets[key]
 	if !exists {
@@ -0,0 +1,239 @@
+package imgio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gioui.org/gesture"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// valueInput is the inline text editor that the Drag and Slider widgets switch to
+// when they are ctrl-clicked or double-clicked.
+type valueInput struct {
+	ui      *Context
+	click   gesture.Click
+	editor  widget.Editor
+	active  bool
+	focused bool
+}
+
+// Add registers the click handler over the current clip area
+func (v *valueInput) Add(ops *op.Ops) {
+	v.click.Add(ops)
+}
+
+// Update processes clicks and returns true while the value is being edited as text
+func (v *valueInput) Update(gtx layout.Context, value float64) bool {
+	for {
+		e, ok := v.click.Update(gtx.Source)
+		if !ok {
+			break
+		}
+		if e.Kind != gesture.KindPress {
+			continue
+		}
+		if e.Modifiers.Contain(key.ModCtrl) || e.NumClicks == 2 {
+			v.start(gtx, value)
+		}
+	}
+	return v.active
+}
+
+func (v *valueInput) start(gtx layout.Context, value float64) {
+	v.editor.SingleLine = true
+	v.editor.Submit = true
+	s := strconv.FormatFloat(value, 'g', -1, 64)
+	v.editor.SetText(s)
+	v.editor.SetCaret(len(s), 0)
+	v.active = true
+	v.focused = false
+	gtx.Execute(key.FocusCmd{Tag: &v.editor})
+	v.ui.Invalidate()
+}
+
+func (v *valueInput) stop(gtx layout.Context) {
+	v.active = false
+	if gtx.Focused(&v.editor) {
+		gtx.Execute(key.FocusCmd{})
+	}
+	v.ui.Invalidate()
+}
+
+// Layout draws the editor and returns the new value and true when an edit is committed.
+// Enter or losing focus commits, Escape cancels.
+func (v *valueInput) Layout(gtx layout.Context, value float64) (float64, bool, layout.Dimensions) {
+	commit, cancel := false, false
+	for {
+		ev, ok := gtx.Event(key.Filter{Focus: &v.editor, Name: key.NameEscape})
+		if !ok {
+			break
+		}
+		if e, ok := ev.(key.Event); ok && e.State == key.Press {
+			cancel = true
+		}
+	}
+	for {
+		ev, ok := v.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			commit = true
+		}
+	}
+	if gtx.Focused(&v.editor) {
+		v.focused = true
+	} else if v.focused {
+		commit = true
+	}
+
+	result, changed := value, false
+	if cancel {
+		v.stop(gtx)
+	} else if commit {
+		if f, err := evalValue(v.editor.Text(), value); err == nil {
+			result, changed = f, f != value
+		}
+		v.stop(gtx)
+	}
+
+	th := v.ui.theme
+	e := material.Editor(th, &v.editor, "")
+	e.TextSize = th.TextSize * 14.0 / 16.0
+	border := widget.Border{Color: th.ContrastBg, Width: unit.Dp(1)}
+	dims := border.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Background{}.Layout(gtx,
+			func(gtx layout.Context) layout.Dimensions {
+				defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+				paint.Fill(gtx.Ops, th.Bg)
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(2)).Layout(gtx, e.Layout)
+			},
+		)
+	})
+	return result, changed, dims
+}
+
+// evalValue parses s as the new value for a numeric widget.  s may be a simple
+// arithmetic expression using + - * / and parentheses.  Following imgui, a leading
+// '+', '*' or '/' applies the expression to current, so "*2" doubles the value.
+func evalValue(s string, current float64) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return current, fmt.Errorf("empty expression")
+	}
+	switch s[0] {
+	case '+', '*', '/':
+		s = strconv.FormatFloat(current, 'g', -1, 64) + s
+	}
+	p := exprParser{s: s}
+	v, err := p.expr()
+	if err != nil {
+		return current, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return current, fmt.Errorf("unexpected %q in %q", p.s[p.pos:], s)
+	}
+	return v, nil
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		var rhs float64
+		rhs, err = p.term()
+		if op == '+' {
+			v += rhs
+		} else {
+			v -= rhs
+		}
+	}
+	return v, err
+}
+
+func (p *exprParser) term() (float64, error) {
+	v, err := p.factor()
+	for err == nil {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			break
+		}
+		p.pos++
+		var rhs float64
+		rhs, err = p.factor()
+		if op == '*' {
+			v *= rhs
+		} else {
+			v /= rhs
+		}
+	}
+	return v, err
+}
+
+func (p *exprParser) factor() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.factor()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.factor()
+	case '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return v, err
+		}
+		if p.peek() != ')' {
+			return v, fmt.Errorf("missing ) in %q", p.s)
+		}
+		p.pos++
+		return v, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("0123456789.eE", p.s[p.pos]) >= 0 {
+		// allow exponents such as 1e-3
+		if (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') && p.pos+1 < len(p.s) && (p.s[p.pos+1] == '-' || p.s[p.pos+1] == '+') {
+			p.pos++
+		}
+		p.pos++
+	}
+	return strconv.ParseFloat(p.s[start:p.pos], 64)
+}
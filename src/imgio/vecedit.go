@@ -0,0 +1,180 @@
+package imgio
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+var (
+	vecComponentNames  = []string{"X", "Y", "Z", "W"}
+	vecComponentColors = []color.NRGBA{
+		{R: 0xc0, G: 0x3a, B: 0x3a, A: 0xff},
+		{R: 0x3a, G: 0x9a, B: 0x3a, A: 0xff},
+		{R: 0x3a, G: 0x5a, B: 0xc0, A: 0xff},
+		{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	}
+)
+
+type dragVecCtx[T ~float32 | ~float64 | ~int | ~int64] struct {
+	values []T
+	drags  []*DragFloatCtx
+}
+
+// DragVec lays out one drag per element of v on a single line.  Methods can't have
+// type parameters, so this is a function rather than a method on Im.
+// returns true if any component changed
+func DragVec[T ~float32 | ~float64 | ~int | ~int64](im *Im, label string, v []T, speed, minv, maxv float64, format string) bool {
+	label, id := getId(label, "dragvec")
+	im.persistWidget(id, (*persistedSlice[T])(&v))
+	changed := false
+	im.WithSameLine(func(im *Im) {
+		changed = dragVecComponents(im, id, v, vecComponentNames, speed, minv, maxv, format)
+		for k, d := range fromCache(im, id, func() *dragVecCtx[T] { return &dragVecCtx[T]{} }).drags[:len(v)] {
+			recordEdit(im, label, fmt.Sprintf("%s/%d", id, k), &v[k], d.active(), d.Changed)
+		}
+		im.WithFlexMode(FlexModeRigid, func(im *Im) {
+			im.Text(label)
+		})
+	})
+	return changed
+}
+
+func (i *Im) DragFloat2(label string, v *[2]float64, speed, minv, maxv float64, format string) bool {
+	return DragVec(i, label, v[:], speed, minv, maxv, format)
+}
+
+func (i *Im) DragFloat3(label string, v *[3]float64, speed, minv, maxv float64, format string) bool {
+	return DragVec(i, label, v[:], speed, minv, maxv, format)
+}
+
+func (i *Im) DragFloat4(label string, v *[4]float64, speed, minv, maxv float64, format string) bool {
+	return DragVec(i, label, v[:], speed, minv, maxv, format)
+}
+
+func (i *Im) DragInt2(label string, v *[2]int64, speed float64, minv, maxv int64, format string) bool {
+	return DragVec(i, label, v[:], speed, float64(minv), float64(maxv), format)
+}
+
+func (i *Im) DragInt3(label string, v *[3]int64, speed float64, minv, maxv int64, format string) bool {
+	return DragVec(i, label, v[:], speed, float64(minv), float64(maxv), format)
+}
+
+func (i *Im) DragInt4(label string, v *[4]int64, speed float64, minv, maxv int64, format string) bool {
+	return DragVec(i, label, v[:], speed, float64(minv), float64(maxv), format)
+}
+
+// dragVecComponents adds a labelled drag for each element of v to the current line.
+// The drags are cached under id, so several vectors can share a line.  Persisting v is
+// left to the caller, which knows whether v is its own or a scratch copy.
+func dragVecComponents[T ~float32 | ~float64 | ~int | ~int64](im *Im, id string, v []T, names []string, speed, minv, maxv float64, format string) bool {
+	ctx := fromCache(im, id, func() *dragVecCtx[T] {
+		return &dragVecCtx[T]{}
+	})
+	// rebind every frame so the callbacks never write to a stale slice
+	ctx.values = v
+	for k := len(ctx.drags); k < len(v); k++ {
+		ctx.drags = append(ctx.drags, makeDragFloatContext(im.ctx, float64(v[k]), speed, minv, maxv, func(delta f32.Point, d *DragFloatCtx) string {
+			ctx.values[k] = T(d.Value)
+			return fmt.Sprintf(format, ctx.values[k])
+		}))
+	}
+	changed := false
+	for k := range v {
+		if T(ctx.drags[k].Value) != v[k] {
+			// changed by the caller
+			ctx.drags[k].Value = float64(v[k])
+		}
+		changed = changed || ctx.drags[k].Changed
+		im.AddWidget(vecComponent(im.theme, names[k%len(names)], vecComponentColors[k%len(vecComponentColors)], ctx.drags[k].w))
+	}
+	return changed
+}
+
+func (i *Im) SliderFloat2(label string, v *[2]float64, min, max float64) bool {
+	return i.sliderVec(label, v[:], min, max)
+}
+
+func (i *Im) SliderFloat3(label string, v *[3]float64, min, max float64) bool {
+	return i.sliderVec(label, v[:], min, max)
+}
+
+func (i *Im) SliderFloat4(label string, v *[4]float64, min, max float64) bool {
+	return i.sliderVec(label, v[:], min, max)
+}
+
+func (i *Im) sliderVec(label string, v []float64, min, max float64) bool {
+	label, id := getId(label, "slidervec")
+	i.persistWidget(id, (*persistedSlice[float64])(&v))
+	changed := false
+	i.WithSameLine(func(im *Im) {
+		for k := range v {
+			name := vecComponentNames[k%len(vecComponentNames)]
+			s := i.slider(fmt.Sprintf("%s/%d", id, k), v[k], min, max, 0, sliderSetter(&v[k]))
+			recordEdit(i, label, fmt.Sprintf("%s/%d", id, k), &v[k], s.active(), s.changed)
+			changed = changed || s.changed
+			value := i.text("% 7.3f", v[k])
+			i.AddWidget(vecComponent(i.theme, name, vecComponentColors[k%len(vecComponentColors)], func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, s.w),
+					layout.Rigid(value),
+				)
+			}))
+		}
+		i.WithFlexMode(FlexModeRigid, func(im *Im) {
+			i.Text(label)
+		})
+	})
+	return changed
+}
+
+// persistedSlice is a caller's slice saved with the window.  Loading copies into the
+// existing elements, so the caller's array is the one restored.
+type persistedSlice[T any] []T
+
+func (s *persistedSlice[T]) UnmarshalJSON(data []byte) error {
+	var v []T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	copy(*s, v)
+	return nil
+}
+
+// vecComponent prefixes w with a small colored label naming the component.  The
+// pair together honours the minimum width that w alone would have been given.
+func vecComponent(th *material.Theme, name string, c color.NRGBA, w layout.Widget) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		minX := gtx.Constraints.Min.X
+		labelWidth := 0
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				dims := layout.Background{}.Layout(gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+						paint.Fill(gtx.Ops, c)
+						return layout.Dimensions{Size: gtx.Constraints.Min}
+					},
+					func(gtx layout.Context) layout.Dimensions {
+						l := material.Label(th, th.TextSize*14.0/16.0, name)
+						l.Color = th.ContrastFg
+						return layout.Inset{Left: unit.Dp(3), Right: unit.Dp(3)}.Layout(gtx, l.Layout)
+					},
+				)
+				labelWidth = dims.Size.X
+				return dims
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = clamp(minX-labelWidth, 0, gtx.Constraints.Max.X)
+				return w(gtx)
+			}),
+		)
+	}
+}
@@ -1,6 +1,7 @@
 package imgio
 
 import (
+	"encoding/json"
 	"image"
 	"image/color"
 
@@ -42,83 +43,158 @@ func (w *WindowManager) Layout(gtx layout.Context) {
 }
 
 type Window struct {
-	Pos           f32.Point
-	Size          f32.Point
+	Pos  f32.Point
+	Size f32.Point
+	// Collapsed shows only the title bar
+	Collapsed     bool
 	parent        *WindowManager
 	dragStartPos  f32.Point
 	dragStartSize f32.Point
 	drag          gesture.Drag
 	closeButton   widget.Clickable
+	collapse      widget.Clickable
 	closed        bool
 	title         string
-	im            *Im
+	// begun is set when the window is added to the current frame
+	begun bool
+	// raised is set when the window is pressed, to bring it to the front
+	raised bool
+	im     *Im
+	// state holds the saved values of persisted widgets, by id
+	state     map[string]json.RawMessage
+	persisted map[string]any
+}
+
+// restore sets the window's placement from raw, saved by json.Marshal.  Fields left
+// out of raw by older saves get their zero value rather than keeping the current one.
+func (w *Window) restore(raw json.RawMessage) {
+	w.Collapsed = false
+	json.Unmarshal(raw, w)
+}
+
+// restoreWidgets switches the window to the persisted widget values in state, loading
+// them into the widgets already persisted.  A nil state keeps the current values.
+func (w *Window) restoreWidgets(state map[string]json.RawMessage) {
+	if state == nil {
+		return
+	}
+	w.state = state
+	for id, v := range w.persisted {
+		if raw, ok := state[id]; ok {
+			json.Unmarshal(raw, v)
+		}
+	}
+}
+
+// saveState copies the persisted widget values into state
+func (w *Window) saveState() {
+	for id, v := range w.persisted {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		if w.state == nil {
+			w.state = map[string]json.RawMessage{}
+		}
+		w.state[id] = raw
+	}
 }
 
 func (w *Window) Layout(gtx layout.Context, child func(gtx layout.Context) layout.Dimensions) layout.Dimensions {
 	if w.closeButton.Clicked(gtx) {
 		w.closed = true
+		w.im.Invalidate()
+	}
+	if w.collapse.Clicked(gtx) {
+		w.Collapsed = !w.Collapsed
+	}
+	th := w.im.theme
+	titlebarHeight := unit.Dp(35)
+	size := w.Size
+	if w.Collapsed {
+		size.Y = float32(gtx.Dp(titlebarHeight))
 	}
 
 	// Apply the window constraints.
-	gtx.Constraints.Max = w.Size.Round()
+	gtx.Constraints.Max = size.Round()
 
 	// Move the window
 	defer op.Offset(w.Pos.Round()).Push(gtx.Ops).Pop()
 
+	// the whole window catches presses, so they don't reach the windows behind it
+	defer clip.Rect{Max: size.Round()}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, &w.raised)
+	forEvent(gtx.Source, pointer.Filter{
+		Target: &w.raised,
+		Kinds:  pointer.Press,
+	}, func(e pointer.Event) bool {
+		w.raised = true
+		return true
+	})
+
 	rect := func(r image.Rectangle, c color.NRGBA) {
 		defer clip.Rect(r).Push(gtx.Ops).Pop()
 		paint.ColorOp{Color: c}.Add(gtx.Ops)
 		paint.PaintOp{}.Add(gtx.Ops)
 	}
 	// draw the outline with a full rect and then an inset rect
-	//rect(image.Rect(0, 0, int(w.Size.X), int(w.Size.Y)), gTheme.ContrastBg)
-	rect(image.Rect(2, 2, int(w.Size.X-2), int(w.Size.Y-2)), gTheme.Bg)
+	//rect(image.Rect(0, 0, int(w.Size.X), int(w.Size.Y)), th.ContrastBg)
+	rect(image.Rect(2, 2, int(size.X-2), int(size.Y-2)), th.Bg)
 	// clip subsequent draws to the window area
-	r := image.Rectangle{Max: w.Size.Round()}
-	paint.FillShape(gtx.Ops, gTheme.ContrastBg, clip.Stroke{
+	r := image.Rectangle{Max: size.Round()}
+	paint.FillShape(gtx.Ops, th.ContrastBg, clip.Stroke{
 		Path:  clip.UniformRRect(r, 0).Path(gtx.Ops),
 		Width: float32(gtx.Metric.Dp(2)),
 	}.Op())
-	defer clip.Rect(image.Rect(2, 2, int(w.Size.X-2), int(w.Size.Y-2))).Push(gtx.Ops).Pop()
+	defer clip.Rect(image.Rect(2, 2, int(size.X-2), int(size.Y-2))).Push(gtx.Ops).Pop()
 
 	// titlebar
-	titlebarHeight := unit.Dp(35)
 	func() {
 		defer clip.Rect(image.Rect(0, 0, int(w.Size.X), gtx.Metric.Dp(titlebarHeight))).Push(gtx.Ops).Pop()
-		paint.ColorOp{Color: gTheme.ContrastBg}.Add(gtx.Ops)
+		paint.ColorOp{Color: th.ContrastBg}.Add(gtx.Ops)
 		paint.PaintOp{}.Add(gtx.Ops)
 		event.Op(gtx.Ops, w)
 
 		titleGtx := gtx
 		titleGtx.Constraints.Max = image.Pt(int(w.Size.X), gtx.Metric.Dp(titlebarHeight))
 		layout.UniformInset(unit.Dp(4)).Layout(titleGtx, func(gtx layout.Context) layout.Dimensions {
-			p := gTheme.Palette
+			p := th.Palette
 			p.Bg, p.Fg = p.Fg, p.Bg
-			th := gTheme.WithPalette(p)
+			inverted := th.WithPalette(p)
+			collapse := "-"
+			if w.Collapsed {
+				collapse = "+"
+			}
 			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
-				layout.Flexed(1, material.Body1(&th, w.title).Layout),
-				layout.Rigid(material.Button(gTheme, &w.closeButton, "X").Layout),
+				layout.Rigid(material.Button(th, &w.collapse, collapse).Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(4)}.Layout),
+				layout.Flexed(1, material.Body1(&inverted, w.title).Layout),
+				layout.Rigid(material.Button(th, &w.closeButton, "X").Layout),
 			)
 		})
 	}()
 
-	// Layout the child
-	layout.Inset{Top: titlebarHeight}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-		return child(gtx)
-	})
+	// a collapsed window shows only its title bar
+	if !w.Collapsed {
+		// Layout the child
+		layout.Inset{Top: titlebarHeight}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return child(gtx)
+		})
+		w.im.drawList.Layout(gtx)
 
-	// draw the corner resize triangle
-	func() {
-		p := clip.Path{}
-		p.Begin(gtx.Ops)
-		p.MoveTo(w.Size)
-		p.Line(f32.Pt(0, -40))
-		p.Line(f32.Pt(-40, 40))
-		defer clip.Outline{Path: p.End()}.Op().Push(gtx.Ops).Pop()
-		paint.ColorOp{Color: gTheme.ContrastBg}.Add(gtx.Ops)
-		paint.PaintOp{}.Add(gtx.Ops)
-		event.Op(gtx.Ops, &w.Pos)
-	}()
+		// draw the corner resize triangle
+		func() {
+			p := clip.Path{}
+			p.Begin(gtx.Ops)
+			p.MoveTo(w.Size)
+			p.Line(f32.Pt(0, -40))
+			p.Line(f32.Pt(-40, 40))
+			defer clip.Outline{Path: p.End()}.Op().Push(gtx.Ops).Pop()
+			paint.ColorOp{Color: th.ContrastBg}.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			event.Op(gtx.Ops, &w.Pos)
+		}()
+	}
 
 	// corner dragging for resize
 	forEvent(gtx.Source, pointer.Filter{
@@ -150,5 +226,5 @@ func (w *Window) Layout(gtx layout.Context, child func(gtx layout.Context) layou
 		//fmt.Printf("local %v %v\n", w, e.Position)
 		return true
 	})
-	return layout.Dimensions{Size: w.Size.Round()}
+	return layout.Dimensions{Size: size.Round()}
 }